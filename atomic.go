@@ -0,0 +1,155 @@
+package zhash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteOption changes behaviour of WriteFileAtomic.
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	backups int
+}
+
+// Keeps n previous versions of file as path.bak.1 (the newest) to
+// path.bak.N (the oldest).
+func KeepBackups(n int) WriteOption {
+	return func(c *writeConfig) {
+		c.backups = n
+	}
+}
+
+// Writes hash to file so readers and crashes never see it half written.
+// Hash is marshalled like WriteFile does into a temporary file in the same
+// directory, which is synced and then renamed over path, and the directory
+// is synced too. If file exists, its mode and (where supported, and if
+// permitted) owner are kept and perm is ignored. Symlinks are followed, so
+// the link target is replaced.
+func (h Hash) WriteFileAtomic(
+	path string, perm os.FileMode, opts ...WriteOption,
+) error {
+	config := writeConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	data, err := h.marshalFor(path)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data, perm, config)
+}
+
+func writeFileAtomic(
+	path string, data []byte, perm os.FileMode, config writeConfig,
+) (err error) {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	dir := filepath.Dir(path)
+	existing, statErr := os.Stat(path)
+	if statErr == nil {
+		perm = existing.Mode().Perm()
+	} else if !os.IsNotExist(statErr) {
+		return statErr
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if existing != nil {
+		if err = chownLike(tmp, existing); err != nil {
+			return err
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if existing != nil && config.backups > 0 {
+		if err = rotateBackups(path, config.backups); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// rotateBackups shifts existing backups of path and stores current file as
+// the first one.
+func rotateBackups(path string, n int) error {
+	if err := os.Remove(backupName(path, n)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := n - 1; i >= 1; i-- {
+		err := os.Rename(backupName(path, i), backupName(path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// hard link keeps current content without copying, as original file is
+	// replaced by rename anyway
+	if err := os.Link(path, backupName(path, 1)); err == nil {
+		return nil
+	}
+	return copyFile(path, backupName(path, 1))
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package zhash
+
+import "os"
+
+// chownLike does nothing, as owners are not supported here.
+func chownLike(f *os.File, existing os.FileInfo) error {
+	return nil
+}
+
+// syncDir does nothing, as directories can not be synced here.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,119 @@
+package zhash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	h := HashFromMap(map[string]interface{}{"version": 1})
+	if err := h.WriteFileAtomic(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode=%v; want 0640", info.Mode().Perm())
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	h.Set(2, "version")
+	if err := h.WriteFileAtomic(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ = os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode of rewritten file=%v; want 0600", info.Mode().Perm())
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != `{"version":2}` {
+		t.Errorf("content=%s", content)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+}
+
+func TestWriteFileAtomicBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	h := NewHash()
+	for version := 1; version <= 4; version++ {
+		h.Set(version, "version")
+		if err := h.WriteFileAtomic(path, 0644, KeepBackups(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		path:                `{"version":4}`,
+		backupName(path, 1): `{"version":3}`,
+		backupName(path, 2): `{"version":2}`,
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(name)
+		if err != nil || string(actual) != content {
+			t.Errorf("%s=%s, %v; want %s", name, actual, err, content)
+		}
+	}
+
+	if _, err := os.Stat(backupName(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("third backup exists: %v", err)
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.json")
+	link := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(target, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+
+	h := HashFromMap(map[string]interface{}{"a": 1})
+	if err := h.WriteFileAtomic(link, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink is replaced: %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != `{"a":1}` {
+		t.Errorf("target content=%s", content)
+	}
+}
+
+func TestWriteFileAtomicFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.conf")
+
+	if err := NewHash().WriteFileAtomic(path, 0644); err == nil {
+		t.Error("WriteFileAtomic without format does not fail")
+	}
+	if err := NewHash().WriteFileAtomic(filepath.Join(dir, "missing", "x.json"), 0644); err == nil {
+		t.Error("WriteFileAtomic into missing directory does not fail")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("files left: %v", entries)
+	}
+}
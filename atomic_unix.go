@@ -0,0 +1,33 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package zhash
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike sets owner of f to owner of existing file. Lack of permission
+// is not an error, as only root can give files away.
+func chownLike(f *os.File, existing os.FileInfo) error {
+	stat, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	err := f.Chown(int(stat.Uid), int(stat.Gid))
+	if err != nil && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
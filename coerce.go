@@ -0,0 +1,183 @@
+package zhash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// Coercion defines how strictly numeric values are converted by GetInt,
+// GetFloat, Get<Type>Slice and generic getters.
+type Coercion int
+
+const (
+	// Numbers are returned only if they are stored with expected type
+	// (int and int64 for GetInt, float64, int and int64 for GetFloat).
+	CoerceStrict Coercion = iota
+
+	// Any numeric value is converted if it can be done exactly: integral
+	// float64 (as json.Unmarshal gives), json.Number, sized ints and uints,
+	// and numeric strings. ErrOverflow or ErrPrecisionLoss is returned
+	// otherwise.
+	CoerceLenient
+)
+
+var (
+	ErrOverflow      = errors.New("value overflows target type")
+	ErrPrecisionLoss = errors.New("value cannot be converted without precision loss")
+)
+
+// Sets numeric coercion mode used by getters. Default is CoerceStrict.
+//
+//	h.SetUnmarshallerFunc(json.Unmarshal)
+//	h.SetCoercion(zhash.CoerceLenient)
+//	port, err := h.GetInt("server", "port") // 8080.0 becomes 8080
+func (h *Hash) SetCoercion(c Coercion) {
+	h.coercion = c
+}
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type float interface {
+	~float32 | ~float64
+}
+
+var lenientConverters = map[reflect.Type]interface{}{
+	typeOf[int]():     Converter[int](coerceInt[int]),
+	typeOf[int8]():    Converter[int8](coerceInt[int8]),
+	typeOf[int16]():   Converter[int16](coerceInt[int16]),
+	typeOf[int32]():   Converter[int32](coerceInt[int32]),
+	typeOf[int64]():   Converter[int64](coerceInt[int64]),
+	typeOf[uint]():    Converter[uint](coerceInt[uint]),
+	typeOf[uint8]():   Converter[uint8](coerceInt[uint8]),
+	typeOf[uint16]():  Converter[uint16](coerceInt[uint16]),
+	typeOf[uint32]():  Converter[uint32](coerceInt[uint32]),
+	typeOf[uint64]():  Converter[uint64](coerceInt[uint64]),
+	typeOf[float32](): Converter[float32](coerceFloat[float32]),
+	typeOf[float64](): Converter[float64](coerceFloat[float64]),
+}
+
+// coerceInt converts any numeric value or numeric string to T if it can be
+// done exactly.
+func coerceInt[T integer](value interface{}) (T, error) {
+	switch val := value.(type) {
+	case json.Number:
+		return parseInt[T](string(val))
+	case string:
+		return parseInt[T](val)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intToInt[T](rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return uintToInt[T](rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return floatToInt[T](rv.Float())
+	}
+
+	return 0, typeError(value)
+}
+
+func parseInt[T integer](s string) (T, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return intToInt[T](i)
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return uintToInt[T](u)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return floatToInt[T](f)
+}
+
+func intToInt[T integer](i int64) (T, error) {
+	result := T(i)
+	if int64(result) != i || (i < 0) != (result < 0) {
+		return 0, fmt.Errorf("%d: %w", i, ErrOverflow)
+	}
+	return result, nil
+}
+
+func uintToInt[T integer](u uint64) (T, error) {
+	result := T(u)
+	if uint64(result) != u || result < 0 {
+		return 0, fmt.Errorf("%d: %w", u, ErrOverflow)
+	}
+	return result, nil
+}
+
+func floatToInt[T integer](f float64) (T, error) {
+	switch {
+	case math.IsNaN(f):
+		return 0, fmt.Errorf("%v: %w", f, ErrPrecisionLoss)
+	case f != math.Trunc(f) && !math.IsInf(f, 0):
+		return 0, fmt.Errorf("%v: %w", f, ErrPrecisionLoss)
+	case f >= -(1<<63) && f < 1<<63:
+		return intToInt[T](int64(f))
+	case f >= 0 && f < 1<<64:
+		return uintToInt[T](uint64(f))
+	}
+	return 0, fmt.Errorf("%v: %w", f, ErrOverflow)
+}
+
+// coerceFloat converts any numeric value or numeric string to T. Integers
+// which can not be represented exactly cause ErrPrecisionLoss.
+func coerceFloat[T float](value interface{}) (T, error) {
+	switch val := value.(type) {
+	case json.Number:
+		return parseFloat[T](string(val))
+	case string:
+		return parseFloat[T](val)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		result := T(i)
+		if float64(result) >= 1<<63 || int64(result) != i {
+			return 0, fmt.Errorf("%d: %w", i, ErrPrecisionLoss)
+		}
+		return result, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		result := T(u)
+		if float64(result) >= 1<<64 || uint64(result) != u {
+			return 0, fmt.Errorf("%d: %w", u, ErrPrecisionLoss)
+		}
+		return result, nil
+	case reflect.Float32, reflect.Float64:
+		return floatToFloat[T](rv.Float())
+	}
+
+	return 0, typeError(value)
+}
+
+func parseFloat[T float](s string) (T, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return floatToFloat[T](f)
+}
+
+func floatToFloat[T float](f float64) (T, error) {
+	result := T(f)
+	if math.IsInf(float64(result), 0) && !math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%v: %w", f, ErrOverflow)
+	}
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+var coerceMap = map[string]interface{}{
+	"float":       10.0,
+	"fraction":    10.5,
+	"huge":        1e20,
+	"number":      json.Number("12"),
+	"int32":       int32(13),
+	"uint8":       uint8(14),
+	"uint64":      uint64(math.MaxUint64),
+	"string":      "15",
+	"text":        "fifteen",
+	"bool":        true,
+	"floatSlice":  []interface{}{1.0, 2.0, json.Number("3")},
+	"fracSlice":   []interface{}{1.0, 2.5},
+	"intSlice":    []int{10, 12, 14},
+	"uint16Slice": []uint16{300, 400},
+}
+
+func TestGetIntLenient(t *testing.T) {
+	hash := HashFromMap(coerceMap)
+	hash.SetCoercion(CoerceLenient)
+
+	tests := []getTest{
+		{[]string{"float"}, int64(10), false},
+		{[]string{"number"}, int64(12), false},
+		{[]string{"int32"}, int64(13), false},
+		{[]string{"uint8"}, int64(14), false},
+		{[]string{"string"}, int64(15), false},
+		{[]string{"fraction"}, int64(0), true},
+		{[]string{"huge"}, int64(0), true},
+		{[]string{"uint64"}, int64(0), true},
+		{[]string{"text"}, int64(0), true},
+		{[]string{"bool"}, int64(0), true},
+		{[]string{"missing"}, int64(0), true},
+	}
+
+	for i, test := range tests {
+		in, err := hash.GetInt(test.path...)
+		checkGet(i, test, in, err, "GetInt", t)
+	}
+
+	_, err := hash.GetInt("fraction")
+	if !errors.Is(err, ErrPrecisionLoss) {
+		t.Errorf("GetInt(fraction) returned %v; want ErrPrecisionLoss", err)
+	}
+	_, err = hash.GetInt("huge")
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("GetInt(huge) returned %v; want ErrOverflow", err)
+	}
+	_, err = Get[uint8](hash, "uint16Slice", "0")
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("Get[uint8](300) returned %v; want ErrOverflow", err)
+	}
+	_, err = Get[uint](HashFromMap(map[string]interface{}{"neg": -1.0}), "neg")
+	if err == nil {
+		t.Errorf("Get[uint] of negative value in strict mode doesn't fail")
+	}
+}
+
+func TestGetIntStrict(t *testing.T) {
+	hash := HashFromMap(coerceMap)
+	for _, key := range []string{"float", "number", "int32", "string"} {
+		if _, err := hash.GetInt(key); err == nil {
+			t.Errorf("GetInt(%s) in strict mode doesn't cause error", key)
+		}
+	}
+}
+
+func TestGetFloatLenient(t *testing.T) {
+	hash := HashFromMap(coerceMap)
+	hash.SetCoercion(CoerceLenient)
+
+	tests := []getTest{
+		{[]string{"fraction"}, 10.5, false},
+		{[]string{"number"}, 12.0, false},
+		{[]string{"uint8"}, 14.0, false},
+		{[]string{"string"}, 15.0, false},
+		{[]string{"uint64"}, 0.0, true},
+		{[]string{"text"}, 0.0, true},
+	}
+
+	for i, test := range tests {
+		f, err := hash.GetFloat(test.path...)
+		checkGet(i, test, f, err, "GetFloat", t)
+	}
+
+	_, err := Get[float32](hash, "huge")
+	if err != nil {
+		t.Errorf("Get[float32](1e20) caused error: %v", err)
+	}
+	big := HashFromMap(map[string]interface{}{"big": 1e300})
+	big.SetCoercion(CoerceLenient)
+	if _, err := Get[float32](big, "big"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Get[float32](1e300) returned %v; want ErrOverflow", err)
+	}
+}
+
+func TestGetSliceLenient(t *testing.T) {
+	hash := HashFromMap(coerceMap)
+	hash.SetCoercion(CoerceLenient)
+
+	ints := []getTest{
+		{[]string{"floatSlice"}, []int64{1, 2, 3}, false},
+		{[]string{"uint16Slice"}, []int64{300, 400}, false},
+		{[]string{"fracSlice"}, []int64{}, true},
+	}
+	for i, test := range ints {
+		s, err := hash.GetIntSlice(test.path...)
+		checkGet(i, test, s, err, "GetIntSlice", t)
+	}
+
+	floats := []getTest{
+		{[]string{"intSlice"}, []float64{10, 12, 14}, false},
+		{[]string{"fracSlice"}, []float64{1, 2.5}, false},
+	}
+	for i, test := range floats {
+		s, err := hash.GetFloatSlice(test.path...)
+		checkGet(i, test, s, err, "GetFloatSlice", t)
+	}
+}
+
+func TestLenientJSON(t *testing.T) {
+	hash := NewHash()
+	hash.SetUnmarshallerFunc(json.Unmarshal)
+	hash.SetCoercion(CoerceLenient)
+
+	err := hash.ReadHash(bytes.NewBufferString(
+		`{"server": {"port": 8080, "workers": [1, 2]}}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := hash.GetHash("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := server.GetInt("port")
+	if err != nil || port != 8080 {
+		t.Errorf("GetInt(port)=%d, %v; want 8080", port, err)
+	}
+	workers, err := server.GetIntSlice("workers")
+	if err != nil || len(workers) != 2 {
+		t.Errorf("GetIntSlice(workers)=%v, %v", workers, err)
+	}
+}
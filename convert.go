@@ -0,0 +1,168 @@
+package zhash
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Converter converts raw value stored in Hash to T. It is called only for
+// found (non nil) values.
+type Converter[T any] func(value interface{}) (T, error)
+
+// converters maps reflect.Type of T to Converter[T]
+var converters sync.Map
+
+func init() {
+	RegisterConverter(assertConverter[string])
+	RegisterConverter(assertConverter[bool])
+	RegisterConverter(func(value interface{}) (int64, error) {
+		switch val := value.(type) {
+		case int:
+			return int64(val), nil
+		case int64:
+			return val, nil
+		}
+		return 0, typeError(value)
+	})
+	RegisterConverter(func(value interface{}) (int, error) {
+		switch val := value.(type) {
+		case int:
+			return val, nil
+		case int64:
+			if int64(int(val)) != val {
+				return 0, fmt.Errorf("%d overflows int", val)
+			}
+			return int(val), nil
+		}
+		return 0, typeError(value)
+	})
+	RegisterConverter(func(value interface{}) (float64, error) {
+		switch val := value.(type) {
+		case float64:
+			return val, nil
+		case int:
+			return float64(val), nil
+		case int64:
+			return float64(val), nil
+		}
+		return 0, typeError(value)
+	})
+}
+
+// Registers converter used by Get and GetSlice for type T, replacing
+// previously registered one. Types without registered converter are
+// retrieved by plain type assertion.
+//
+//	zhash.RegisterConverter(func(v interface{}) (netip.Addr, error) {
+//		s, ok := v.(string)
+//		if !ok {
+//			return netip.Addr{}, fmt.Errorf("%T is not a string", v)
+//		}
+//		return netip.ParseAddr(s)
+//	})
+func RegisterConverter[T any](conv Converter[T]) {
+	converters.Store(typeOf[T](), conv)
+}
+
+func converterFor[T any](h Hash) Converter[T] {
+	if h.coercion == CoerceLenient {
+		if conv, ok := lenientConverters[typeOf[T]()]; ok {
+			return conv.(Converter[T])
+		}
+	}
+
+	if conv, ok := converters.Load(typeOf[T]()); ok {
+		return conv.(Converter[T])
+	}
+
+	return assertConverter[T]
+}
+
+func assertConverter[T any](value interface{}) (T, error) {
+	typed, ok := value.(T)
+	if !ok {
+		return typed, typeError(value)
+	}
+
+	return typed, nil
+}
+
+func typeError(value interface{}) error {
+	return fmt.Errorf("unexpected type %T", value)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Retrieves value of type T from hash using converter registered for T.
+// Numeric types are converted by lenient rules if hash coercion mode is
+// CoerceLenient. Returns not found error if nothing found.
+func Get[T any](h Hash, path ...string) (T, error) {
+	return get(h, converterFor[T](h), path)
+}
+
+// Retrieves slice of T from hash. Any slice is accepted, each of its
+// elements is converted using converter registered for T.
+func GetSlice[T any](h Hash, path ...string) ([]T, error) {
+	return getSlice(h, converterFor[T](h), path)
+}
+
+func get[T any](h Hash, conv Converter[T], path []string) (T, error) {
+	var zero T
+
+	value, err := h.getValue(path)
+	if err != nil {
+		return zero, err
+	}
+	if value == nil {
+		return zero, notFoundError{path}
+	}
+
+	result, err := conv(value)
+	if err != nil {
+		return zero, fmt.Errorf(
+			"cannot convert %s to %s: %w",
+			strings.Join(path, "."), typeOf[T](), err,
+		)
+	}
+
+	return result, nil
+}
+
+func getSlice[T any](h Hash, conv Converter[T], path []string) ([]T, error) {
+	value, err := h.getValue(path)
+	if err != nil {
+		return []T{}, err
+	}
+	if value == nil {
+		return []T{}, notFoundError{path}
+	}
+
+	if typed, ok := value.([]T); ok {
+		return typed, nil
+	}
+
+	slice, ok := asSlice(value)
+	if !ok {
+		return []T{}, fmt.Errorf(
+			"cannot convert %s to []%s", strings.Join(path, "."), typeOf[T](),
+		)
+	}
+
+	result := make([]T, slice.Len())
+	for i := range result {
+		elem, err := conv(slice.Index(i).Interface())
+		if err != nil {
+			return []T{}, fmt.Errorf(
+				"cannot convert %s to []%s, element %d: %w",
+				strings.Join(path, "."), typeOf[T](), i, err,
+			)
+		}
+		result[i] = elem
+	}
+
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package zhash
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+type testLevel int
+
+const (
+	levelDebug testLevel = iota
+	levelInfo
+)
+
+func init() {
+	RegisterConverter(func(value interface{}) (testLevel, error) {
+		switch value {
+		case "debug":
+			return levelDebug, nil
+		case "info":
+			return levelInfo, nil
+		}
+		return 0, fmt.Errorf("unknown level %v", value)
+	})
+	RegisterConverter(func(value interface{}) (netip.Addr, error) {
+		s, ok := value.(string)
+		if !ok {
+			return netip.Addr{}, typeError(value)
+		}
+		return netip.ParseAddr(s)
+	})
+}
+
+func TestGetGeneric(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"int":    10,
+		"int64":  int64(11),
+		"string": "some text",
+		"level":  "info",
+		"bad":    "trace",
+		"addr":   "10.0.0.1",
+	})
+
+	tests := []getTest{
+		{[]string{"int"}, 10, false},
+		{[]string{"int64"}, 11, false},
+		{[]string{"string"}, 0, true},
+		{[]string{"missing"}, 0, true},
+	}
+	for i, test := range tests {
+		v, err := Get[int](hash, test.path...)
+		checkGet(i, test, v, err, "Get[int]", t)
+	}
+
+	level, err := Get[testLevel](hash, "level")
+	if err != nil || level != levelInfo {
+		t.Errorf("Get[testLevel]()=%v, %v; want %v", level, err, levelInfo)
+	}
+	_, err = Get[testLevel](hash, "bad")
+	if err == nil || IsNotFound(err) {
+		t.Errorf("Get[testLevel](bad) returned %v", err)
+	}
+
+	addr, err := Get[netip.Addr](hash, "addr")
+	if err != nil || addr != netip.MustParseAddr("10.0.0.1") {
+		t.Errorf("Get[netip.Addr]()=%v, %v", addr, err)
+	}
+
+	s, err := Get[fmt.Stringer](hash, "string")
+	if err == nil || s != nil {
+		t.Errorf("Get[fmt.Stringer]()=%v, %v; want error", s, err)
+	}
+}
+
+func TestGetSliceGeneric(t *testing.T) {
+	hash := HashFromMap(testMap)
+	tests := []getTest{
+		{[]string{"intSlice"}, []int{10, 12, 14}, false},
+		{[]string{"intIMixSlice"}, []int{30, 32, 34}, false},
+		{[]string{"fltSlice"}, []int{}, true},
+		{[]string{"int"}, []int{}, true},
+		{[]string{"not", "exists"}, []int{}, true},
+	}
+
+	for i, test := range tests {
+		s, err := GetSlice[int](hash, test.path...)
+		checkGet(i, test, s, err, "GetSlice[int]", t)
+	}
+
+	hash.Set([]interface{}{"debug", "info"}, "levels")
+	levels, err := GetSlice[testLevel](hash, "levels")
+	if err != nil || len(levels) != 2 || levels[1] != levelInfo {
+		t.Errorf("GetSlice[testLevel]()=%v, %v", levels, err)
+	}
+}
+
+func TestRegisterConverterOverride(t *testing.T) {
+	type celsius float64
+
+	hash := HashFromMap(map[string]interface{}{"temp": 36.6})
+	if _, err := Get[celsius](hash, "temp"); err == nil {
+		t.Errorf("Get[celsius] without converter doesn't cause error")
+	}
+
+	RegisterConverter(func(value interface{}) (celsius, error) {
+		f, ok := value.(float64)
+		if !ok {
+			return 0, errors.New("not a float")
+		}
+		return celsius(f), nil
+	})
+
+	temp, err := Get[celsius](hash, "temp")
+	if err != nil || temp != 36.6 {
+		t.Errorf("Get[celsius]()=%v, %v", temp, err)
+	}
+}
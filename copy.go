@@ -0,0 +1,71 @@
+package zhash
+
+import "reflect"
+
+// copyValue returns deep copy of maps and slices found in value. Yaml maps
+// are converted to map[string]interface{} on the way.
+func copyValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, val := range typed {
+			result[key] = copyValue(val)
+		}
+		return result
+	case map[interface{}]interface{}:
+		return copyValue(convertToMapString(typed))
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, val := range typed {
+			result[i] = copyValue(val)
+		}
+		return result
+	}
+
+	slice, ok := asSlice(value)
+	if !ok {
+		return value
+	}
+
+	result := reflect.MakeSlice(slice.Type(), slice.Len(), slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		elem := slice.Index(i)
+		copied := reflect.ValueOf(copyValue(elem.Interface()))
+		if copied.IsValid() && copied.Type().AssignableTo(elem.Type()) {
+			elem = copied
+		}
+		result.Index(i).Set(elem)
+	}
+	return result.Interface()
+}
+
+// replaceRoot replaces content of h.data by content of root in place, so all
+// copies of h see the change.
+func (h Hash) replaceRoot(root map[string]interface{}) {
+	h.notifyChange(nil, func() error {
+		for key := range h.data {
+			delete(h.data, key)
+		}
+		for key, val := range root {
+			h.data[key] = val
+		}
+		return nil
+	})
+}
+
+// deepCopy returns hash holding deep copy of h data and the same settings.
+// Change listeners are not copied.
+func (h Hash) deepCopy() Hash {
+	c := h
+	c.data = copyValue(h.data).(map[string]interface{})
+	c.listeners = newListeners()
+	return c
+}
+
+// emptyCopy returns empty hash having the same settings as h.
+func (h Hash) emptyCopy() Hash {
+	c := h
+	c.data = map[string]interface{}{}
+	c.listeners = newListeners()
+	return c
+}
@@ -0,0 +1,417 @@
+package zhash
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldError describes single value which can not be decoded.
+type FieldError struct {
+	Path []string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", strings.Join(e.Path, "."), e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned by Decode and lists every value which can not be
+// decoded.
+type DecodeError struct {
+	Errors []*FieldError
+}
+
+func (e *DecodeError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf(
+		"cannot decode %d value(s): %s",
+		len(e.Errors), strings.Join(messages, "; "),
+	)
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decodes hash subtree found under the path into v, which must be a non nil
+// pointer. Struct fields are matched with map keys by `zhash`, `json` or
+// `yaml` tag, whichever is found first, like HashFromStruct does, or by
+// field name (case insensitive) if there is no tag. Tag "-" skips the field.
+// Embedded structs without tag and fields with "inline" option are decoded
+// from the same map as the outer struct, inline maps get keys not matched
+// by other fields.
+//
+//	type Config struct {
+//		Host    string        `zhash:"host"`
+//		Timeout time.Duration `zhash:"timeout"`
+//		Users   []User        `zhash:"users"`
+//	}
+//
+//	var cfg Config
+//	err := h.Decode(&cfg, "server")
+//
+// Numbers are converted by CoerceLenient rules, time.Duration is decoded
+// from strings like "1m30s" or from nanoseconds, types implementing
+// encoding.TextUnmarshaler and types having registered converter are decoded
+// by them. Missing keys leave fields untouched. All failed values are
+// reported together in *DecodeError.
+func (h Hash) Decode(v interface{}, path ...string) error {
+	return h.decode(v, path, false)
+}
+
+// decode is Decode, which deep copies maps and slices assigned to interface
+// fields if copied is true, so v shares nothing with h.
+func (h Hash) decode(v interface{}, path []string, copied bool) error {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return fmt.Errorf("cannot decode into %T, expected non nil pointer", v)
+	}
+
+	var (
+		src interface{}
+		err error
+	)
+	if len(path) > 0 {
+		src, err = h.getValue(path)
+	} else {
+		src, err = h.rootValue()
+	}
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return notFoundError{path}
+	}
+
+	d := decoder{copied: copied}
+	d.decode(dst.Elem(), src, append([]string{}, path...))
+	if len(d.errors) > 0 {
+		return &DecodeError{d.errors}
+	}
+
+	return nil
+}
+
+type decoder struct {
+	copied bool
+	errors []*FieldError
+}
+
+func (d *decoder) fail(path []string, err error) {
+	d.errors = append(d.errors, &FieldError{
+		Path: append([]string{}, path...),
+		Err:  err,
+	})
+}
+
+func (d *decoder) decode(dst reflect.Value, src interface{}, path []string) {
+	if src == nil {
+		return
+	}
+
+	if dst.Type() == durationType {
+		d.decodeDuration(dst, src, path)
+		return
+	}
+
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if s, ok := src.(string); ok {
+			u := dst.Addr().Interface().(encoding.TextUnmarshaler)
+			if err := u.UnmarshalText([]byte(s)); err != nil {
+				d.fail(path, err)
+			}
+			return
+		}
+	}
+
+	if dst.Type().PkgPath() != "" {
+		if conv, ok := converters.Load(dst.Type()); ok {
+			out := reflect.ValueOf(conv).Call(
+				[]reflect.Value{reflect.ValueOf(&src).Elem()},
+			)
+			if err, _ := out[1].Interface().(error); err != nil {
+				d.fail(path, err)
+				return
+			}
+			dst.Set(out[0])
+			return
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		d.decode(dst.Elem(), src, path)
+
+	case reflect.Struct:
+		m, ok := toStringMap(src)
+		if !ok {
+			d.fail(path, typeError(src))
+			return
+		}
+		d.decodeStruct(dst, m, path)
+
+	case reflect.Map:
+		d.decodeMap(dst, src, path)
+
+	case reflect.Slice:
+		d.decodeSlice(dst, src, path)
+
+	case reflect.Interface:
+		if d.copied {
+			src = copyValue(src)
+		}
+		value := reflect.ValueOf(src)
+		if !value.Type().AssignableTo(dst.Type()) {
+			d.fail(path, typeError(src))
+			return
+		}
+		dst.Set(value)
+
+	case reflect.String:
+		s, ok := src.(string)
+		if !ok {
+			d.fail(path, typeError(src))
+			return
+		}
+		dst.SetString(s)
+
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			d.fail(path, typeError(src))
+			return
+		}
+		dst.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := coerceInt[int64](src)
+		if err == nil && dst.OverflowInt(i) {
+			err = fmt.Errorf("%d: %w", i, ErrOverflow)
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		dst.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		u, err := coerceInt[uint64](src)
+		if err == nil && dst.OverflowUint(u) {
+			err = fmt.Errorf("%d: %w", u, ErrOverflow)
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		dst.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := coerceFloat[float64](src)
+		if err == nil && dst.OverflowFloat(f) {
+			err = fmt.Errorf("%v: %w", f, ErrOverflow)
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		dst.SetFloat(f)
+
+	default:
+		d.fail(path, fmt.Errorf("unsupported type %s", dst.Type()))
+	}
+}
+
+func (d *decoder) decodeDuration(
+	dst reflect.Value, src interface{}, path []string,
+) {
+	if s, ok := src.(string); ok {
+		duration, err := time.ParseDuration(s)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		dst.SetInt(int64(duration))
+		return
+	}
+
+	nanoseconds, err := coerceInt[int64](src)
+	if err != nil {
+		d.fail(path, err)
+		return
+	}
+	dst.SetInt(nanoseconds)
+}
+
+func (d *decoder) decodeStruct(
+	dst reflect.Value, src map[string]interface{}, path []string,
+) {
+	used := map[string]bool{}
+	var inlineMaps []reflect.Value
+	d.decodeFields(dst, src, path, used, &inlineMaps)
+
+	if len(inlineMaps) == 0 {
+		return
+	}
+
+	// inline maps get keys not taken by fields, like yaml does
+	rest := map[string]interface{}{}
+	for key, value := range src {
+		if !used[key] {
+			rest[key] = value
+		}
+	}
+	for _, m := range inlineMaps {
+		d.decodeMap(m, rest, path)
+	}
+}
+
+func (d *decoder) decodeFields(
+	dst reflect.Value, src map[string]interface{}, path []string,
+	used map[string]bool, inlineMaps *[]reflect.Value,
+) {
+	structType := dst.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, options := fieldTag(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := dst.Field(i)
+		if options["inline"] || (field.Anonymous && name == "") {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			switch fieldType.Kind() {
+			case reflect.Struct:
+				if field.Type.Kind() == reflect.Ptr {
+					if !fieldValue.CanSet() {
+						continue
+					}
+					if fieldValue.IsNil() {
+						fieldValue.Set(reflect.New(fieldType))
+					}
+					fieldValue = fieldValue.Elem()
+				}
+				d.decodeFields(fieldValue, src, path, used, inlineMaps)
+				continue
+			case reflect.Map:
+				if field.IsExported() && field.Type.Kind() == reflect.Map {
+					*inlineMaps = append(*inlineMaps, fieldValue)
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		key, ok := lookupKey(src, name)
+		if !ok {
+			continue
+		}
+
+		used[key] = true
+		d.decode(fieldValue, src[key], append(path, key))
+	}
+}
+
+// lookupKey finds key in m equal to name, or equal under case folding if
+// there is no exact match.
+func lookupKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func (d *decoder) decodeMap(dst reflect.Value, src interface{}, path []string) {
+	m, ok := toStringMap(src)
+	if !ok {
+		d.fail(path, typeError(src))
+		return
+	}
+
+	mapType := dst.Type()
+	if mapType.Key().Kind() != reflect.String {
+		d.fail(path, fmt.Errorf("unsupported map key type %s", mapType.Key()))
+		return
+	}
+
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(mapType, len(m)))
+	}
+
+	for key, val := range m {
+		elem := reflect.New(mapType.Elem()).Elem()
+		failed := len(d.errors)
+		d.decode(elem, val, append(path, key))
+		if len(d.errors) == failed {
+			dst.SetMapIndex(reflect.ValueOf(key).Convert(mapType.Key()), elem)
+		}
+	}
+}
+
+func (d *decoder) decodeSlice(
+	dst reflect.Value, src interface{}, path []string,
+) {
+	slice, ok := asSlice(src)
+	if !ok {
+		d.fail(path, typeError(src))
+		return
+	}
+
+	result := reflect.MakeSlice(dst.Type(), slice.Len(), slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		d.decode(
+			result.Index(i), slice.Index(i).Interface(),
+			append(path, fmt.Sprint(i)),
+		)
+	}
+
+	dst.Set(result)
+}
+
+// toStringMap returns src as map[string]interface{}, converting yaml maps.
+func toStringMap(src interface{}) (map[string]interface{}, bool) {
+	switch m := src.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		return convertToMapString(m), true
+	}
+	return nil, false
+}
@@ -0,0 +1,146 @@
+package zhash
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decodeBase struct {
+	Name string `zhash:"name"`
+}
+
+type decodeUser struct {
+	Login string `zhash:"login"`
+	Admin bool   `zhash:"admin"`
+}
+
+type decodeConfig struct {
+	decodeBase
+
+	Host     string            `zhash:"host"`
+	Port     uint16            `zhash:"port"`
+	Ratio    float32           `zhash:"ratio"`
+	Timeout  time.Duration     `zhash:"timeout"`
+	Interval time.Duration     `zhash:"interval"`
+	Addr     netip.Addr        `zhash:"addr"`
+	Level    testLevel         `zhash:"level"`
+	Users    []decodeUser      `zhash:"users"`
+	Limits   map[string]int    `zhash:"limits"`
+	Primary  *decodeUser       `zhash:"primary"`
+	Tags     []string          `zhash:"tags"`
+	Extra    interface{}       `zhash:"extra"`
+	Labels   map[string]string `zhash:"-"`
+	Verbose  bool
+	Missing  string `zhash:"missing"`
+}
+
+func TestDecode(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"server": map[string]interface{}{
+			"name":     "api",
+			"host":     "localhost",
+			"port":     8080.0,
+			"ratio":    0.5,
+			"timeout":  "1m30s",
+			"interval": int64(time.Second),
+			"addr":     "10.0.0.1",
+			"level":    "info",
+			"users": []interface{}{
+				map[string]interface{}{"login": "root", "admin": true},
+				map[interface{}]interface{}{"login": "guest"},
+			},
+			"limits":  map[interface{}]interface{}{"cpu": 2, "mem": 1024.0},
+			"primary": map[string]interface{}{"login": "root"},
+			"tags":    []string{"a", "b"},
+			"extra":   []interface{}{1, "x"},
+			"Labels":  map[string]interface{}{"a": "b"},
+			"verbose": true,
+		},
+	})
+
+	var cfg decodeConfig
+	cfg.Missing = "keep"
+	if err := hash.Decode(&cfg, "server"); err != nil {
+		t.Fatalf("Decode caused error: %v", err)
+	}
+
+	expected := decodeConfig{
+		decodeBase: decodeBase{Name: "api"},
+		Host:       "localhost",
+		Port:       8080,
+		Ratio:      0.5,
+		Timeout:    90 * time.Second,
+		Interval:   time.Second,
+		Addr:       netip.MustParseAddr("10.0.0.1"),
+		Level:      levelInfo,
+		Users: []decodeUser{
+			{Login: "root", Admin: true},
+			{Login: "guest"},
+		},
+		Limits:  map[string]int{"cpu": 2, "mem": 1024},
+		Primary: &decodeUser{Login: "root"},
+		Tags:    []string{"a", "b"},
+		Extra:   []interface{}{1, "x"},
+		Verbose: true,
+		Missing: "keep",
+	}
+
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Decode()=%#v; want %#v", cfg, expected)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"host":    10,
+		"port":    70000,
+		"timeout": "soon",
+		"users": []interface{}{
+			map[string]interface{}{"login": "root"},
+			map[string]interface{}{"login": "guest", "admin": "yes"},
+		},
+		"ratio": 0.5,
+	})
+
+	var cfg decodeConfig
+	err := hash.Decode(&cfg)
+
+	decodeErr, ok := err.(*DecodeError)
+	if !ok {
+		t.Fatalf("Decode returned %v; want *DecodeError", err)
+	}
+
+	paths := map[string]bool{}
+	for _, fieldErr := range decodeErr.Errors {
+		paths[FormatPath(fieldErr.Path)] = true
+		if fieldErr.Path[0] == "port" && !errors.Is(fieldErr, ErrOverflow) {
+			t.Errorf("port error is %v; want ErrOverflow", fieldErr)
+		}
+	}
+
+	expected := map[string]bool{
+		"host": true, "port": true, "timeout": true, "users.1.admin": true,
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("DecodeError paths=%v; want %v", paths, expected)
+	}
+
+	if cfg.Ratio != 0.5 || len(cfg.Users) != 2 {
+		t.Errorf("Decode doesn't fill valid fields: %#v", cfg)
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	hash := NewHash()
+
+	var cfg decodeConfig
+	if err := hash.Decode(&cfg, "server"); !IsNotFound(err) {
+		t.Errorf("Decode of missing subtree returned %v", err)
+	}
+	if err := hash.Decode(cfg); err == nil {
+		t.Errorf("Decode into non pointer doesn't cause error")
+	}
+}
@@ -0,0 +1,218 @@
+package zhash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ChangeKind tells how value differs between two hashes.
+type ChangeKind int
+
+const (
+	// Value exists in the second hash only.
+	ChangeAdded ChangeKind = iota
+	// Value exists in the first hash only.
+	ChangeRemoved
+	// Value of the same type has changed.
+	ChangeChanged
+	// Value has changed its type, for example map became a string.
+	ChangeTypeChanged
+)
+
+var changeKindNames = map[ChangeKind]string{
+	ChangeAdded:       "added",
+	ChangeRemoved:     "removed",
+	ChangeChanged:     "changed",
+	ChangeTypeChanged: "type-changed",
+}
+
+func (k ChangeKind) String() string {
+	if name, ok := changeKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change is a single difference found by Diff. Old is nil for added values
+// and New is nil for removed ones.
+type Change struct {
+	Path []string
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+// Compares two hashes and returns list of changes turning a into b, ordered
+// by path. Maps are compared recursively and slices element by element,
+// numbers are equal if they have equal values whatever their types are.
+func Diff(a, b Hash) []Change {
+	changes := []Change{}
+	diffValues(&changes, []string{}, a.data, b.data)
+	return changes
+}
+
+func diffValues(changes *[]Change, path []string, a, b interface{}) {
+	change := func(kind ChangeKind, path []string, old, new interface{}) {
+		*changes = append(*changes, Change{
+			Path: append([]string{}, path...),
+			Kind: kind,
+			Old:  copyValue(old),
+			New:  copyValue(new),
+		})
+	}
+
+	if valueKind(a) != valueKind(b) {
+		change(ChangeTypeChanged, path, a, b)
+		return
+	}
+
+	if aMap, ok := toStringMap(a); ok {
+		bMap, _ := toStringMap(b)
+		keys := sortedKeys(aMap)
+		for _, key := range sortedKeys(bMap) {
+			if _, ok := aMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			aVal, inA := aMap[key]
+			bVal, inB := bMap[key]
+			switch {
+			case !inB:
+				change(ChangeRemoved, append(path, key), aVal, nil)
+			case !inA:
+				change(ChangeAdded, append(path, key), nil, bVal)
+			default:
+				diffValues(changes, append(path, key), aVal, bVal)
+			}
+		}
+		return
+	}
+
+	if aSlice, ok := asSlice(a); ok {
+		bSlice, _ := asSlice(b)
+		for i := 0; i < aSlice.Len() || i < bSlice.Len(); i++ {
+			index := append(path, strconv.Itoa(i))
+			switch {
+			case i >= bSlice.Len():
+				change(ChangeRemoved, index, aSlice.Index(i).Interface(), nil)
+			case i >= aSlice.Len():
+				change(ChangeAdded, index, nil, bSlice.Index(i).Interface())
+			default:
+				diffValues(
+					changes, index,
+					aSlice.Index(i).Interface(), bSlice.Index(i).Interface(),
+				)
+			}
+		}
+		return
+	}
+
+	if !valuesEqual(a, b) {
+		change(ChangeChanged, path, a, b)
+	}
+}
+
+// valueKind returns JSON type name of value, so values of different kinds
+// are reported as ChangeTypeChanged.
+func valueKind(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if _, ok := toNumber(value); ok {
+		return "number"
+	}
+	if _, ok := toStringMap(value); ok {
+		return "object"
+	}
+	if _, ok := asSlice(value); ok {
+		return "array"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+// Writes changes as unified text report, one line per value: old values are
+// prefixed by "-" and new values by "+", like `- db.host: "localhost"`.
+// Values are written as JSON. If color is true, lines are colorized with ANSI
+// escape sequences.
+//
+//	err := zhash.WriteDiff(os.Stdout, zhash.Diff(deployed, next), true)
+func WriteDiff(w io.Writer, changes []Change, color bool) error {
+	line := func(sign, clr string, path []string, value interface{}) error {
+		text := fmt.Sprintf("%s %s: %s", sign, FormatPath(path), formatValue(value))
+		if color {
+			text = clr + text + colorReset
+		}
+		_, err := fmt.Fprintln(w, text)
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Kind != ChangeAdded {
+			if err := line("-", colorRed, change.Path, change.Old); err != nil {
+				return err
+			}
+		}
+		if change.Kind != ChangeRemoved {
+			if err := line("+", colorGreen, change.Path, change.New); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(copyValue(value))
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
+
+// Writes changes as JSON array of objects having "path", "kind", "old" and
+// "new" keys. Path is formatted by FormatPath, "old" is omitted for added
+// values and "new" is omitted for removed ones.
+func WriteDiffJSON(w io.Writer, changes []Change) error {
+	report := make([]map[string]interface{}, len(changes))
+	for i, change := range changes {
+		entry := map[string]interface{}{
+			"path": FormatPath(change.Path),
+			"kind": change.Kind,
+		}
+		if change.Kind != ChangeAdded {
+			entry["old"] = change.Old
+		}
+		if change.Kind != ChangeRemoved {
+			entry["new"] = change.New
+		}
+		report[i] = entry
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,136 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func diffBase() (Hash, Hash) {
+	a := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+			"user": "admin",
+		},
+		"servers": []interface{}{"a", "b", "c"},
+		"debug":   true,
+		"limits":  map[interface{}]interface{}{"cpu": 1},
+	})
+	b := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432.0,
+			"pool": 10,
+		},
+		"servers": []interface{}{"a", "d"},
+		"debug":   "yes",
+		"limits":  map[string]interface{}{"cpu": 1.0},
+	})
+	return a, b
+}
+
+func TestDiff(t *testing.T) {
+	a, b := diffBase()
+
+	expected := []Change{
+		{[]string{"db", "host"}, ChangeChanged, "localhost", "db.example.com"},
+		{[]string{"db", "pool"}, ChangeAdded, nil, 10},
+		{[]string{"db", "user"}, ChangeRemoved, "admin", nil},
+		{[]string{"debug"}, ChangeTypeChanged, true, "yes"},
+		{[]string{"servers", "1"}, ChangeChanged, "b", "d"},
+		{[]string{"servers", "2"}, ChangeRemoved, "c", nil},
+	}
+
+	changes := Diff(a, b)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Diff()=%#v; want %#v", changes, expected)
+	}
+
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("Diff(a, a)=%#v; want no changes", changes)
+	}
+}
+
+func TestDiffTypeChanged(t *testing.T) {
+	a := HashFromMap(map[string]interface{}{
+		"map":   map[string]interface{}{"key": 1},
+		"slice": []interface{}{1},
+		"null":  nil,
+	})
+	b := HashFromMap(map[string]interface{}{
+		"map":   []interface{}{1},
+		"slice": []int64{1},
+		"null":  "value",
+	})
+
+	expected := []Change{
+		{[]string{"map"}, ChangeTypeChanged,
+			map[string]interface{}{"key": 1}, []interface{}{1}},
+		{[]string{"null"}, ChangeTypeChanged, nil, "value"},
+	}
+
+	changes := Diff(a, b)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Diff()=%#v; want %#v", changes, expected)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	a, b := diffBase()
+	changes := Diff(a, b)
+
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, changes, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `- db.host: "localhost"
++ db.host: "db.example.com"
++ db.pool: 10
+- db.user: "admin"
+- debug: true
++ debug: "yes"
+- servers.1: "b"
++ servers.1: "d"
+- servers.2: "c"
+`
+	if buf.String() != expected {
+		t.Errorf("WriteDiff()=\n%s\nwant\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := WriteDiff(&buf, changes[1:2], true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "\x1b[32m+ db.pool: 10\x1b[0m\n"
+	if buf.String() != expected {
+		t.Errorf("WriteDiff(color)=%q; want %q", buf.String(), expected)
+	}
+}
+
+func TestWriteDiffJSON(t *testing.T) {
+	a, b := diffBase()
+
+	var buf bytes.Buffer
+	if err := WriteDiffJSON(&buf, Diff(a, b)[:3]); err != nil {
+		t.Fatal(err)
+	}
+
+	var report []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]interface{}{
+		{"path": "db.host", "kind": "changed",
+			"old": "localhost", "new": "db.example.com"},
+		{"path": "db.pool", "kind": "added", "new": 10.0},
+		{"path": "db.user", "kind": "removed", "old": "admin"},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("WriteDiffJSON()=%#v; want %#v", report, expected)
+	}
+}
@@ -20,6 +20,27 @@
 
 		h.ReadHash(fd)
 
+	Or let registered formats choose the pair by file extension. Json, toml and
+	yaml are registered out of the box, others can be added by RegisterFormat:
+		err := h.ReadFile("config.yaml")
+		err = h.WriteFile("config.json")
+
+	ReadFile can compose config from several files, merging files listed
+	under include key (globs are allowed) into the including map:
+		h.SetIncludeKey("include")
+		err := h.ReadFile("deploy.yaml") // include: [base.yaml, conf.d/*.yaml]
+
+	WriteFileAtomic replaces file via rename of synced temporary file, so
+	crash never leaves it truncated, and optionally keeps backups:
+		err := h.WriteFileAtomic("config.json", 0644, zhash.KeepBackups(3))
+
+	LockedFile guards load-modify-save cycle of several tools editing the
+	same file, by lock or by detecting changes made since load:
+		f, err := zhash.OpenLocked("config.json")
+		f.Hash().Set(8080, "server", "port")
+		err = f.Save()
+		f.Close()
+
 	Accessing data
 
 	So, you have your hash. How can you access it's data? It's simple --- use
@@ -27,6 +48,55 @@
 	Set for changing items, Delete for deleting childs of nested (or not)
 	maps, and Append<Type>Slice for appending slices.
 
+	Values of any other type are retrieved by generic Get and GetSlice
+	functions. Register converter for your type via RegisterConverter:
+		addr, err := zhash.Get[netip.Addr](h, "server", "addr")
+
+	Numbers
+
+	By default GetInt accepts only int and int64 values. Hashes read by
+	json.Unmarshal contain float64 numbers only, so set CoerceLenient mode
+	for them. In this mode any numeric value is converted if it can be done
+	exactly, ErrOverflow or ErrPrecisionLoss is returned otherwise:
+		h.SetCoercion(zhash.CoerceLenient)
+
+	String paths
+
+	Every accessor has a P variant taking path as a single string, so paths
+	can come from config, flags or logs. Keys are separated by dots, indexes
+	are written in brackets and backslash escapes dots and brackets in keys:
+		host, err := h.GetStringP("db.primary.host")
+		h.SetP(8080, `servers[2].ports.example\.com`)
+
+	Path and ParsePath convert such string into plain []string path.
+
+	Path elements pointing into slices are treated as indexes, so
+	h.Get("deploy", "chmod", "1", "mode") walks through second element of
+	"chmod" list. Set replaces element under index, or grows the slice if
+	index is beyond its end, and Delete removes element from the slice.
+
+	Decoding structs
+
+	Decode fills a struct from hash subtree, matching fields by `zhash`, `json`
+	or `yaml` tags:
+		var cfg struct {
+			Host    string        `zhash:"host"`
+			Timeout time.Duration `zhash:"timeout"`
+		}
+		err := h.Decode(&cfg, "server")
+
+	HashFromStruct does the opposite: it builds Hash from struct using the
+	same tags, so the result can be written via WriteHash or decoded back.
+
+	Yaml maps
+
+	Yaml libraries give map[interface{}]interface{}, which zhash converts to
+	map[string]interface{}, formatting non string keys by fmt.Sprint. Getters
+	return converted copies and never change the hash, while Set, Delete and
+	Append* convert maps on their path in place. Normalize converts the whole
+	hash at once, so maps returned by GetMap or GetHash write through, and
+	SetNormalize makes ReadHash do it after every read.
+
 	Setting data
 
 	Set make no difference on what was there before setting new value. So,
@@ -34,6 +104,105 @@
 	Also Set creates all needed parents if needed, and replaces any found
 	element in the way by map[string]interface{}. So be double careful with Set.
 
+	If you don't want to loose anything, use SetStrict. It returns
+	*ConflictError naming the element in the way instead of replacing it.
+	SetIfAbsent and SetDefault never overwrite existing value at all:
+		port, err := h.SetDefault(8080, "server", "port")
+
+	Validation
+
+	Validate checks hash against Schema and reports every violation with its
+	path. Schema is built in Go or loaded from JSON Schema document:
+		schema := zhash.SchemaObject().
+			Property("port", zhash.SchemaInteger().Min(1).Max(65535)).
+			Required("port")
+		err := h.Validate(schema)
+
+	Interpolation
+
+	With interpolation enabled, string values may refer to other values and
+	environment variables, references are resolved by getters:
+		h.SetInterpolation(true)
+		h.Set("postgres://${db.host}:${db.port:-5432}", "db", "url")
+		h.Set("${env:HOME}/data", "data_dir")
+		url, err := h.GetString("db", "url")
+
+	Merging hashes
+
+	Merge deep merges one hash into another. By default values (and whole
+	slices) of merged hash win, use options to change it for given paths:
+		err := h.Merge(overrides,
+			zhash.MergeSlicesByKey("name", "servers"),
+			zhash.MergeScalars(zhash.ScalarKeep, "secrets"),
+		)
+
+	Patches
+
+	ApplyPatch applies RFC 6902 JSON Patch operations atomically, and
+	CreatePatch generates patch turning one hash into another:
+		err := h.ApplyPatch(zhash.CreatePatch(old, new))
+
+	MergePatch applies RFC 7396 JSON Merge Patch, where nil value deletes the
+	key, and CreateMergePatch builds such patch:
+		h.MergePatch(zhash.CreateMergePatch(old, new))
+
+	Diff compares two hashes and returns list of added, removed and changed
+	values, WriteDiff and WriteDiffJSON render it for review:
+		err := zhash.WriteDiff(os.Stdout, zhash.Diff(deployed, next), true)
+
+	Environment
+
+	LoadEnv sets values from environment variables having given prefix,
+	APP_DB__PRIMARY__HOST becomes db.primary.host:
+		env := zhash.NewHash()
+		err := env.LoadEnv("APP")
+
+	Command line overrides
+
+	ApplySet, ApplySetString and ApplySetFile parse Helm style --set
+	overrides, SetFlag and friends plug them into flag package:
+		flag.Var(zhash.SetFlag(h), "set", "override values (a.b[0].c=10)")
+
+	Layers
+
+	Layers keeps ordered stack of named hashes and resolves each path against
+	the topmost layer defining it. Explain tells which layer value came from:
+		layers := zhash.NewLayers()
+		layers.Add("defaults", defaults)
+		layers.Add("file", file)
+		host, err := layers.GetString("db", "host")
+		fmt.Print(layers.Explain("db", "host"))
+
+	Concurrent access
+
+	Hash is not safe for concurrent use. Wrap it into SyncHash when it is read
+	and changed from several goroutines. Update and CompareAndSet change
+	values atomically:
+		s := zhash.NewSyncHash(h)
+		s.Update(func(old interface{}) interface{} {
+			count, _ := old.(int)
+			return count + 1
+		}, "stats", "requests")
+
+	Snapshot returns read-only copy of hash, which can be read from any
+	goroutine without locks. Holder swaps snapshots atomically on reload:
+		holder := zhash.NewHolder(h)
+		err := holder.ReadHash(fd)
+		port, err := holder.Load().GetInt("server", "port")
+
+	WatchFile loads hash from file and reloads it when the file changes,
+	keeping previous value if new content can not be parsed:
+		w, err := zhash.WatchFile("config.json", h)
+		w.OnReload(func(old, new zhash.Hash) {
+			zhash.WriteDiff(os.Stderr, zhash.Diff(old, new), false)
+		})
+
+	OnChange subscribes to changes of values under path pattern, "*" matches
+	any single key:
+		cancel, err := h.OnChange("servers.*.port", func(old, new interface{}) {
+			log.Printf("port changed from %v to %v", old, new)
+		})
+
 	Appending slices
 
 	Append<Type>Slice will succeed if Get<Type>Slice return no err, or err is
@@ -0,0 +1,238 @@
+package zhash
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Builds Hash from struct (or pointer to struct, or map with string keys)
+// using reflection. Field names are taken from `zhash`, `json` or `yaml` tag,
+// whichever is found first, or field name is used as is. Tag options
+// "omitempty" and "inline" are supported, tag "-" skips the field. Embedded
+// structs without tag are inlined too.
+//
+// Nested structs and maps become map[string]interface{}, slices become
+// []interface{}, time.Duration and types implementing encoding.TextMarshaler
+// become strings, named basic types are converted to underlying ones. So
+// resulting hash can be written with WriteHash, or decoded back with Decode.
+func HashFromStruct(v interface{}) (Hash, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct && value.Kind() != reflect.Map {
+		return NewHash(), fmt.Errorf(
+			"cannot build hash from %T, expected struct or map", v,
+		)
+	}
+
+	encoded, err := encodeValue(value)
+	if err != nil {
+		return NewHash(), err
+	}
+
+	m, ok := encoded.(map[string]interface{})
+	if !ok {
+		return NewHash(), fmt.Errorf("cannot build hash from nil %T", v)
+	}
+
+	return HashFromMap(m), nil
+}
+
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
+
+func encodeValue(value reflect.Value) (interface{}, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	if value.Type() == durationType {
+		return value.Interface().(fmt.Stringer).String(), nil
+	}
+
+	if value.Type().Implements(textMarshalerType) {
+		switch value.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if value.IsNil() {
+				return nil, nil
+			}
+		}
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(value.Elem())
+
+	case reflect.Struct:
+		result := map[string]interface{}{}
+		err := encodeStruct(result, value)
+		return result, err
+
+	case reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+		result := make(map[string]interface{}, value.Len())
+		err := encodeMap(result, value)
+		return result, err
+
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil, nil
+		}
+		result := make([]interface{}, value.Len())
+		for i := range result {
+			elem, err := encodeValue(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = elem
+		}
+		return result, nil
+	}
+
+	if basic, ok := basicTypes[value.Kind()]; ok {
+		return value.Convert(basic).Interface(), nil
+	}
+
+	return nil, fmt.Errorf("cannot encode %s", value.Type())
+}
+
+func encodeStruct(result map[string]interface{}, value reflect.Value) error {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, options := fieldTag(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		inline := options["inline"] || (field.Anonymous && name == "")
+		if inline && !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if inline {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+
+			switch fieldValue.Kind() {
+			case reflect.Struct:
+				if err := encodeStruct(result, fieldValue); err != nil {
+					return err
+				}
+				continue
+			case reflect.Map:
+				if field.IsExported() {
+					if err := encodeMap(result, fieldValue); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			if field.Anonymous && name == "" {
+				// nil embedded pointer
+				continue
+			}
+			fieldValue = value.Field(i)
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if options["omitempty"] && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		encoded, err := encodeValue(fieldValue)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		result[name] = encoded
+	}
+
+	return nil
+}
+
+func encodeMap(result map[string]interface{}, value reflect.Value) error {
+	iter := value.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+		encoded, err := encodeValue(iter.Value())
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		result[key] = encoded
+	}
+
+	return nil
+}
+
+// fieldTag returns name and options from first found of zhash, json and yaml
+// tags.
+func fieldTag(field reflect.StructField) (string, map[string]bool) {
+	for _, key := range []string{"zhash", "json", "yaml"} {
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		options := map[string]bool{}
+		for _, option := range parts[1:] {
+			options[option] = true
+		}
+
+		return parts[0], options
+	}
+
+	return "", map[string]bool{}
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+
+	return value.IsZero()
+}
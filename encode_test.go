@@ -0,0 +1,162 @@
+package zhash
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type encodeMeta struct {
+	Owner string `yaml:"owner"`
+}
+
+type encodeServer struct {
+	decodeBase
+	*encodeMeta
+
+	Host    string            `zhash:"host"`
+	Port    int               `json:"port"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Addr    netip.Addr        `json:"addr"`
+	Level   testLevel         `json:"level"`
+	Users   []decodeUser      `json:"users,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+	Primary *decodeUser       `json:"primary,omitempty"`
+	Extra   map[string]string `yaml:",inline"`
+	Limits  map[int]float64   `json:"limits"`
+	Secret  string            `json:"-"`
+	Verbose bool
+	hidden  string
+}
+
+func TestHashFromStruct(t *testing.T) {
+	server := encodeServer{
+		decodeBase: decodeBase{Name: "api"},
+		encodeMeta: &encodeMeta{Owner: "ops"},
+		Host:       "localhost",
+		Port:       8080,
+		Timeout:    90 * time.Second,
+		Addr:       netip.MustParseAddr("10.0.0.1"),
+		Level:      levelInfo,
+		Users:      []decodeUser{{Login: "root", Admin: true}},
+		Extra:      map[string]string{"zone": "eu"},
+		Limits:     map[int]float64{1: 0.5},
+		Secret:     "password",
+		hidden:     "hidden",
+	}
+
+	hash, err := HashFromStruct(&server)
+	if err != nil {
+		t.Fatalf("HashFromStruct caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":    "api",
+		"owner":   "ops",
+		"host":    "localhost",
+		"port":    8080,
+		"timeout": "1m30s",
+		"addr":    "10.0.0.1",
+		"level":   int(levelInfo),
+		"users": []interface{}{
+			map[string]interface{}{"login": "root", "admin": true},
+		},
+		"zone":    "eu",
+		"limits":  map[string]interface{}{"1": 0.5},
+		"Verbose": false,
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("HashFromStruct()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	hash.SetMarshallerFunc(json.Marshal)
+	if _, err := hash.Reader(); err != nil {
+		t.Errorf("WriteHash of struct hash caused error: %v", err)
+	}
+}
+
+func TestHashFromStructRoundTrip(t *testing.T) {
+	user := decodeUser{Login: "root", Admin: true}
+	hash, err := HashFromStruct(user)
+	if err != nil {
+		t.Fatalf("HashFromStruct caused error: %v", err)
+	}
+
+	var decoded decodeUser
+	if err := hash.Decode(&decoded); err != nil {
+		t.Fatalf("Decode caused error: %v", err)
+	}
+
+	if decoded != user {
+		t.Errorf("Decode(HashFromStruct(%#v))=%#v", user, decoded)
+	}
+}
+
+type encodeTagged struct {
+	decodeBase
+	DBHost string            `json:"db_host"`
+	Port   int               `yaml:"port"`
+	Meta   encodeMeta        `json:",inline"`
+	Extra  map[string]string `yaml:",inline"`
+}
+
+func TestHashFromStructTagsRoundTrip(t *testing.T) {
+	config := encodeTagged{
+		decodeBase: decodeBase{Name: "api"},
+		DBHost:     "db.local",
+		Port:       5432,
+		Meta:       encodeMeta{Owner: "ops"},
+		Extra:      map[string]string{"zone": "eu"},
+	}
+	hash, err := HashFromStruct(config)
+	if err != nil {
+		t.Fatalf("HashFromStruct caused error: %v", err)
+	}
+
+	var decoded encodeTagged
+	if err := hash.Decode(&decoded); err != nil {
+		t.Fatalf("Decode caused error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("Decode(HashFromStruct(%#v))=%#v", config, decoded)
+	}
+}
+
+func TestHashFromStructSkipped(t *testing.T) {
+	config := struct {
+		Name   string                 `json:"name"`
+		Level  encoding.TextMarshaler `json:"level"`
+		hidden encodeMeta             `yaml:",inline"`
+	}{Name: "api", hidden: encodeMeta{Owner: "ops"}}
+
+	hash, err := HashFromStruct(config)
+	if err != nil {
+		t.Fatalf("HashFromStruct caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{"name": "api", "level": nil}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("HashFromStruct()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestHashFromStructErrors(t *testing.T) {
+	if _, err := HashFromStruct(10); err == nil {
+		t.Errorf("HashFromStruct(10) doesn't cause error")
+	}
+
+	var nilServer *encodeServer
+	if _, err := HashFromStruct(nilServer); err == nil {
+		t.Errorf("HashFromStruct(nil) doesn't cause error")
+	}
+
+	withChan := struct{ C chan int }{make(chan int)}
+	if _, err := HashFromStruct(withChan); err == nil {
+		t.Errorf("HashFromStruct with chan field doesn't cause error")
+	}
+}
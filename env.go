@@ -0,0 +1,234 @@
+package zhash
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvOption configures LoadEnv.
+type EnvOption func(*envConfig)
+
+type envConfig struct {
+	separator     string
+	listSeparator string
+	fileSuffix    string
+	keepCase      bool
+	infer         bool
+	environ       []string
+}
+
+// Sets separator between path elements in variable names, default is "__".
+func EnvSeparator(separator string) EnvOption {
+	return func(c *envConfig) {
+		c.separator = separator
+	}
+}
+
+// Sets separator of list elements in values, default is ",". Empty
+// separator disables lists.
+func EnvListSeparator(separator string) EnvOption {
+	return func(c *envConfig) {
+		c.listSeparator = separator
+	}
+}
+
+// Sets suffix of variables holding name of file to read value from, default
+// is "_FILE". Empty suffix disables reading files.
+func EnvFileSuffix(suffix string) EnvOption {
+	return func(c *envConfig) {
+		c.fileSuffix = suffix
+	}
+}
+
+// Keeps case of variable names, by default keys are lowercased.
+func EnvKeepCase() EnvOption {
+	return func(c *envConfig) {
+		c.keepCase = true
+	}
+}
+
+// Disables type inference, so all values are set as strings.
+func EnvNoInference() EnvOption {
+	return func(c *envConfig) {
+		c.infer = false
+	}
+}
+
+// Reads variables from given list of "key=value" strings instead of
+// os.Environ().
+func EnvFrom(environ []string) EnvOption {
+	return func(c *envConfig) {
+		c.environ = environ
+	}
+}
+
+// Sets values from environment variables starting with prefix and "_".
+// Rest of variable name is split by separator into path and lowercased, so
+// with prefix "APP" variable APP_DB__PRIMARY__HOST sets value under
+// db.primary.host.
+//
+// Values looking like ints, floats or bools are set as int, float64 or bool,
+// values containing commas become []interface{} with each element inferred
+// separately. Variable with "_FILE" suffix sets content of the named file
+// (with trailing newline trimmed) as a string, so APP_DB__PASSWORD_FILE
+// sets db.password. The suffix is looked for in the last path element only,
+// so APP_LOG__FILE sets log.file as usual. Both plain and "_FILE" variable
+// for the same path cause an error.
+func (h Hash) LoadEnv(prefix string, opts ...EnvOption) error {
+	config := envConfig{
+		separator:     "__",
+		listSeparator: ",",
+		fileSuffix:    "_FILE",
+		infer:         true,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	environ := config.environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	vars := map[string]string{}
+	for _, env := range environ {
+		name, value, ok := strings.Cut(env, "=")
+		if ok && strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			vars[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := strings.Split(
+			strings.TrimPrefix(name, prefix), config.separator,
+		)
+		last := len(path) - 1
+
+		var value interface{}
+		if stripped, ok := config.fileKey(path[last]); ok {
+			plain := strings.TrimSuffix(name, config.fileSuffix)
+			if _, ok := vars[plain]; ok {
+				return fmt.Errorf("both %s and %s are set", name, plain)
+			}
+
+			content, err := os.ReadFile(vars[name])
+			if err != nil {
+				return fmt.Errorf("cannot read %s: %w", name, err)
+			}
+
+			path[last] = stripped
+			value = strings.TrimSuffix(
+				strings.TrimSuffix(string(content), "\n"), "\r",
+			)
+		} else if config.infer {
+			value = inferValue(vars[name], config.listSeparator)
+		} else {
+			value = vars[name]
+		}
+
+		if hasEmptyKey(path) {
+			continue
+		}
+		if !config.keepCase {
+			for i := range path {
+				path[i] = strings.ToLower(path[i])
+			}
+		}
+
+		h.Set(value, path...)
+	}
+
+	return nil
+}
+
+// fileKey strips file suffix from the last path element of variable name.
+// Element is not a file one if nothing is left after stripping, or if the
+// rest ends with a part of separator, like "LOG_" left from "LOG_FILE" by
+// suffix "FILE".
+func (c envConfig) fileKey(key string) (string, bool) {
+	if c.fileSuffix == "" || !strings.HasSuffix(key, c.fileSuffix) {
+		return "", false
+	}
+
+	stripped := strings.TrimSuffix(key, c.fileSuffix)
+	if stripped == "" {
+		return "", false
+	}
+	for i := 1; i <= len(c.separator); i++ {
+		if strings.HasSuffix(stripped, c.separator[:i]) {
+			return "", false
+		}
+	}
+
+	return stripped, true
+}
+
+func hasEmptyKey(path []string) bool {
+	for _, p := range path {
+		if p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	leadingZeroPattern = regexp.MustCompile(`^[+-]?0[0-9]`)
+	intPattern         = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	floatPattern       = regexp.MustCompile(
+		`^[+-]?([0-9]+\.[0-9]*|\.[0-9]+|[0-9]+)([eE][+-]?[0-9]+)?$`,
+	)
+)
+
+// inferValue converts string to int, float64, bool or list of them if it
+// looks like one. Numbers with leading zeros ("007") stay strings.
+func inferValue(s string, listSeparator string) interface{} {
+	if listSeparator != "" && strings.Contains(s, listSeparator) {
+		elems := strings.Split(s, listSeparator)
+		list := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			list[i] = inferValue(strings.TrimSpace(elem), "")
+		}
+		return list
+	}
+
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if leadingZeroPattern.MatchString(s) {
+		return s
+	}
+
+	if intPattern.MatchString(s) {
+		if i, err := strconv.Atoi(s); err == nil {
+			return i
+		}
+		return s
+	}
+
+	if floatPattern.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return s
+}
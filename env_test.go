@@ -0,0 +1,155 @@
+package zhash
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{"port": 5432, "user": "admin"},
+	})
+
+	err := hash.LoadEnv("APP", EnvFrom([]string{
+		"APP_DB__PRIMARY__HOST=db.example.com",
+		"APP_DB__PORT=6432",
+		"APP_DB__PASSWORD_FILE=" + secret,
+		"APP_RATIO=0.75",
+		"APP_DEBUG=true",
+		"APP_ZIP=007",
+		"APP_HOSTS=a, b,c",
+		"APP_PORTS=80,443",
+		"APP_NAME=api",
+		"APP___BROKEN=1",
+		"OTHER_VAR=1",
+		"APP_=empty",
+	}))
+	if err != nil {
+		t.Fatalf("LoadEnv caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"port":     6432,
+			"user":     "admin",
+			"password": "s3cret",
+			"primary":  map[string]interface{}{"host": "db.example.com"},
+		},
+		"ratio": 0.75,
+		"debug": true,
+		"zip":   "007",
+		"hosts": []interface{}{"a", "b", "c"},
+		"ports": []interface{}{80, 443},
+		"name":  "api",
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("LoadEnv()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestLoadEnvOptions(t *testing.T) {
+	hash := NewHash()
+	err := hash.LoadEnv("", EnvFrom([]string{
+		"Db.Host=localhost",
+		"Db.Port=5432",
+		"List=a,b",
+		"LOG_FILE=/var/log/app.log",
+	}),
+		EnvSeparator("."),
+		EnvKeepCase(),
+		EnvNoInference(),
+		EnvListSeparator(""),
+		EnvFileSuffix(""),
+	)
+	if err != nil {
+		t.Fatalf("LoadEnv caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"Db":       map[string]interface{}{"Host": "localhost", "Port": "5432"},
+		"List":     "a,b",
+		"LOG_FILE": "/var/log/app.log",
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("LoadEnv()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestLoadEnvFileSuffixAfterSeparator(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(output, []byte("content\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := NewHash()
+	err := hash.LoadEnv("APP", EnvFrom([]string{
+		"APP_LOG__FILE=/var/log/app.log",
+		"APP_OUTPUT__FILE=" + output,
+	}))
+	if err != nil {
+		t.Fatalf("LoadEnv caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"log":    map[string]interface{}{"file": "/var/log/app.log"},
+		"output": map[string]interface{}{"file": output},
+	}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("LoadEnv()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	hash = NewHash()
+	err = hash.LoadEnv("APP", EnvFrom([]string{
+		"APP_LOG_FILE=/var/log/app.log",
+	}), EnvSeparator("_"), EnvFileSuffix("FILE"))
+	if err != nil {
+		t.Fatalf("LoadEnv caused error: %v", err)
+	}
+
+	expected = map[string]interface{}{
+		"log": map[string]interface{}{"file": "/var/log/app.log"},
+	}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("LoadEnv()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	hash := NewHash()
+	err := hash.LoadEnv("APP", EnvFrom([]string{
+		"APP_KEY=a",
+		"APP_KEY_FILE=/nonexistent",
+	}))
+	if err == nil {
+		t.Errorf("LoadEnv with both KEY and KEY_FILE doesn't cause error")
+	}
+
+	err = hash.LoadEnv("APP", EnvFrom([]string{
+		"APP_KEY_FILE=" + filepath.Join(t.TempDir(), "nonexistent"),
+	}))
+	if err == nil {
+		t.Errorf("LoadEnv with missing file doesn't cause error")
+	}
+}
+
+func TestLoadEnvOsEnviron(t *testing.T) {
+	t.Setenv("ZHASH_TEST_VALUE", "10")
+
+	hash := NewHash()
+	if err := hash.LoadEnv("ZHASH_TEST"); err != nil {
+		t.Fatalf("LoadEnv caused error: %v", err)
+	}
+
+	if v, err := hash.GetInt("value"); v != 10 || err != nil {
+		t.Errorf("GetInt(value)=%d, %v; want 10", v, err)
+	}
+}
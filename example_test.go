@@ -56,3 +56,40 @@ func Example_readHash() {
 	// Output:
 	// This is working
 }
+
+// Example Get shows how to retrieve values of any type using generic Get and
+// custom converters
+func Example_genericGet() {
+	type level int
+
+	zhash.RegisterConverter(func(v interface{}) (level, error) {
+		switch v {
+		case "debug":
+			return 0, nil
+		case "info":
+			return 1, nil
+		}
+		return 0, fmt.Errorf("unknown level %v", v)
+	})
+
+	h := zhash.HashFromMap(map[string]interface{}{
+		"log": map[string]interface{}{
+			"level": "info",
+			"ports": []interface{}{8080, 8081},
+		},
+	})
+
+	l, err := zhash.Get[level](h, "log", "level")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ports, err := zhash.GetSlice[int](h, "log", "ports")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(l, ports)
+	// Output:
+	// 1 [8080 8081]
+}
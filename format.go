@@ -0,0 +1,254 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is a named pair of Marshaller and Unmarshaller. Formats are
+// registered by RegisterFormat and then found by name, file extension or
+// content.
+type Format struct {
+	// Name of format, like "json"
+	Name string
+	// File extensions with leading dot, like ".yaml" and ".yml"
+	Extensions []string
+	Marshal    Marshaller
+	Unmarshal  Unmarshaller
+	// Sniff reports if data looks like this format, it is optional
+	Sniff func(data []byte) bool
+}
+
+var formats = struct {
+	sync.RWMutex
+	list []Format
+}{}
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Marshal:    json.Marshal,
+		Unmarshal:  json.Unmarshal,
+		Sniff:      sniffJSON,
+	})
+	RegisterFormat(Format{
+		Name:       "toml",
+		Extensions: []string{".toml"},
+		Marshal:    toml.Marshal,
+		Unmarshal:  toml.Unmarshal,
+		Sniff:      sniffTOML,
+	})
+	// yaml goes last, as json is yaml too
+	RegisterFormat(Format{
+		Name:       "yaml",
+		Extensions: []string{".yaml", ".yml"},
+		Marshal:    yaml.Marshal,
+		Unmarshal:  yaml.Unmarshal,
+		Sniff:      sniffYAML,
+	})
+}
+
+func sniffJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{' && json.Valid(data)
+}
+
+// sniffTOML and sniffYAML accept data which parses into a non empty map, as
+// both formats have no distinctive start.
+func sniffTOML(data []byte) bool {
+	m := map[string]interface{}{}
+	return toml.Unmarshal(data, &m) == nil && len(m) > 0
+}
+
+func sniffYAML(data []byte) bool {
+	m := map[interface{}]interface{}{}
+	return yaml.Unmarshal(data, &m) == nil && len(m) > 0
+}
+
+// Registers format, replacing registered format with the same name. Json,
+// toml and yaml are registered out of the box, in this order, and other
+// formats or other libraries for them can be registered once in your
+// program:
+//
+//	zhash.RegisterFormat(zhash.Format{
+//		Name:       "yaml",
+//		Extensions: []string{".yaml", ".yml"},
+//		Marshal:    yamlv3.Marshal,
+//		Unmarshal:  yamlv3.Unmarshal,
+//	})
+func RegisterFormat(f Format) {
+	if f.Name == "" {
+		panic("zhash: format without name")
+	}
+
+	formats.Lock()
+	defer formats.Unlock()
+
+	for i := range formats.list {
+		if formats.list[i].Name == f.Name {
+			formats.list[i] = f
+			return
+		}
+	}
+	formats.list = append(formats.list, f)
+}
+
+// Returns format registered under given name.
+func LookupFormat(name string) (Format, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	for _, f := range formats.list {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Returns format registered for extension of given file name. Extensions are
+// compared case insensitively.
+func FormatForFile(path string) (Format, bool) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return Format{}, false
+	}
+
+	formats.RLock()
+	defer formats.RUnlock()
+
+	for _, f := range formats.list {
+		for _, e := range f.Extensions {
+			if strings.EqualFold(e, ext) {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// Returns first format, in order of registration, whose Sniff function
+// accepts data.
+func DetectFormat(data []byte) (Format, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+
+	for _, f := range formats.list {
+		if f.Sniff != nil && f.Sniff(data) {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Sets marshaller and unmarshaller of registered format, so they never
+// mismatch.
+func (h *Hash) SetFormat(name string) error {
+	f, ok := LookupFormat(name)
+	if !ok {
+		return fmt.Errorf("unknown format %q", name)
+	}
+
+	h.setFormat(f)
+	return nil
+}
+
+func (h *Hash) setFormat(f Format) {
+	h.marshal = f.Marshal
+	h.unmarshal = f.Unmarshal
+}
+
+// Reads hash from r using registered format with given name, which becomes
+// format of hash.
+func (h *Hash) ReadHashAs(r io.Reader, name string) error {
+	if err := h.SetFormat(name); err != nil {
+		return err
+	}
+	return h.ReadHash(r)
+}
+
+// Reads hash from r detecting its format by content, see DetectFormat.
+// Detected format becomes format of hash.
+func (h *Hash) ReadHashAuto(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	f, ok := DetectFormat(data)
+	if !ok {
+		return errors.New("cannot detect format of data")
+	}
+
+	h.setFormat(f)
+	return h.ReadHash(bytes.NewReader(data))
+}
+
+// Reads hash from file. Format is chosen by file extension, or detected by
+// content if extension is unknown, and becomes format of hash. Include
+// directives are processed if enabled by SetIncludeKey.
+func (h *Hash) ReadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := h.readFileData(path, data); err != nil {
+		return err
+	}
+
+	if h.includeKey != "" {
+		return h.applyIncludes(path)
+	}
+	return nil
+}
+
+func (h *Hash) readFileData(path string, data []byte) error {
+	f, ok := FormatForFile(path)
+	if !ok {
+		f, ok = DetectFormat(data)
+	}
+	if !ok {
+		return fmt.Errorf("cannot detect format of %s", path)
+	}
+
+	h.setFormat(f)
+	if err := h.ReadHash(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return nil
+}
+
+// Writes hash to file using format chosen by file extension, or marshaller
+// of hash if extension is unknown.
+func (h Hash) WriteFile(path string) error {
+	data, err := h.marshalFor(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (h Hash) marshalFor(path string) ([]byte, error) {
+	marshal := h.marshal
+	if f, ok := FormatForFile(path); ok {
+		marshal = f.Marshal
+	}
+	if marshal == nil {
+		return nil, fmt.Errorf("cannot marshal hash to %s, unknown format", path)
+	}
+
+	return marshal(h.data)
+}
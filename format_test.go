@@ -0,0 +1,127 @@
+package zhash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookupFormat(t *testing.T) {
+	tests := []struct {
+		file string
+		name string
+		ok   bool
+	}{
+		{"config.json", "json", true},
+		{"config.YML", "yaml", true},
+		{"dir.yaml/config.yaml", "yaml", true},
+		{"config.toml", "toml", true},
+		{"config.ini", "", false},
+		{"config", "", false},
+	}
+
+	for _, test := range tests {
+		f, ok := FormatForFile(test.file)
+		if ok != test.ok || f.Name != test.name {
+			t.Errorf("FormatForFile(%q)=%q, %v; want %q, %v",
+				test.file, f.Name, ok, test.name, test.ok)
+		}
+	}
+
+	if _, ok := LookupFormat("json"); !ok {
+		t.Error("json format is not registered")
+	}
+	if _, ok := LookupFormat("xml"); ok {
+		t.Error("xml format is registered")
+	}
+
+	for data, name := range map[string]string{
+		` {"a": 1}`:  "json",
+		"a: 1\n":     "yaml",
+		"[a]\nb = 1": "toml",
+		"{broken":    "",
+		"just text":  "",
+	} {
+		f, _ := DetectFormat([]byte(data))
+		if f.Name != name {
+			t.Errorf("DetectFormat(%q)=%q; want %q", data, f.Name, name)
+		}
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	source := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{"host": "localhost", "port": 5432},
+	})
+
+	for _, name := range []string{"config.json", "config.yml", "config.conf"} {
+		path := filepath.Join(dir, name)
+		source.SetFormat("yaml")
+		if err := source.WriteFile(path); err != nil {
+			t.Fatalf("WriteFile(%s): %v", name, err)
+		}
+
+		h := NewHash()
+		h.SetCoercion(CoerceLenient)
+		if err := h.ReadFile(path); err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+
+		port, _ := h.GetInt("db", "port")
+		if host, _ := h.GetString("db", "host"); host != "localhost" || port != 5432 {
+			t.Errorf("ReadFile(%s)=%s", name, h)
+		}
+
+		var buf bytes.Buffer
+		if err := h.WriteHash(&buf); err != nil {
+			t.Errorf("WriteHash after ReadFile(%s): %v", name, err)
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if !strings.HasPrefix(string(content), "{") {
+		t.Errorf("config.json is not json: %s", content)
+	}
+
+	if err := NewHash().WriteFile(filepath.Join(dir, "x.conf")); err == nil {
+		t.Error("WriteFile without format does not fail")
+	}
+	os.WriteFile(filepath.Join(dir, "x.conf"), []byte("[broken"), 0644)
+	if err := NewHashPtr().ReadFile(filepath.Join(dir, "x.conf")); err == nil {
+		t.Error("ReadFile of unknown format does not fail")
+	}
+}
+
+func TestReadHashAs(t *testing.T) {
+	h := NewHash()
+	if err := h.ReadHashAs(strings.NewReader("a:\n  b: [1, 2]\n"), "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.Get("a", "b"), []interface{}{1, 2}) {
+		t.Errorf("ReadHashAs(yaml)=%#v", h.GetRoot())
+	}
+
+	if err := h.ReadHashAs(strings.NewReader("[a]\nb = [1, 2]\n"), "toml"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.Get("a", "b"), []interface{}{int64(1), int64(2)}) {
+		t.Errorf("ReadHashAs(toml)=%#v", h.GetRoot())
+	}
+
+	if err := h.ReadHashAs(strings.NewReader("{}"), "xml"); err == nil {
+		t.Error("ReadHashAs(xml) does not fail")
+	}
+
+	h = NewHash()
+	if err := h.ReadHashAuto(strings.NewReader(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if h.Get("a") != 1.0 {
+		t.Errorf("ReadHashAuto(json)=%#v", h.GetRoot())
+	}
+}
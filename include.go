@@ -0,0 +1,191 @@
+package zhash
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Enables include directives for ReadFile: map key with given name (like
+// "include") holds file name or list of file names, which are loaded and
+// deep merged into the map containing the key, and the key is removed.
+// Values of including map win over included ones, and later files win over
+// earlier ones. Relative names are resolved against directory of including
+// file, and glob patterns like "conf.d/*.yaml" are expanded in lexical order.
+// Included files may include other files, include cycles are errors.
+//
+// Format of included file is chosen by its extension, otherwise unmarshaller
+// of hash is used, otherwise format is detected by content. Empty key
+// disables includes, which is the default.
+//
+//	h.SetIncludeKey("include")
+//	err := h.ReadFile("deploy.yaml") // include: [base.yaml, conf.d/*.yaml]
+func (h *Hash) SetIncludeKey(key string) {
+	h.includeKey = key
+}
+
+// applyIncludes processes include directives of hash read from path.
+func (h Hash) applyIncludes(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	root, err := h.include(copyValue(h.data), filepath.Dir(abs), []string{abs})
+	if err != nil {
+		return err
+	}
+
+	h.replaceRoot(root.(map[string]interface{}))
+	return nil
+}
+
+// include processes include directives found in node and its descendants.
+// Chain lists files being included, from the outermost one.
+func (h Hash) include(node interface{}, dir string, chain []string) (interface{}, error) {
+	if slice, ok := node.([]interface{}); ok {
+		for i, elem := range slice {
+			included, err := h.include(elem, dir, chain)
+			if err != nil {
+				return nil, err
+			}
+			slice[i] = included
+		}
+		return slice, nil
+	}
+
+	m, ok := toStringMap(node)
+	if !ok {
+		return node, nil
+	}
+
+	for key, val := range m {
+		if key == h.includeKey {
+			continue
+		}
+		included, err := h.include(val, dir, chain)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = included
+	}
+
+	directive, ok := m[h.includeKey]
+	if !ok {
+		return m, nil
+	}
+	delete(m, h.includeKey)
+
+	files, err := includedFiles(directive, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{} = map[string]interface{}{}
+	merger := &mergeConfig{}
+	for _, file := range files {
+		for i, including := range chain {
+			if including == file {
+				cycle := append(append([]string{}, chain[i:]...), file)
+				return nil, fmt.Errorf(
+					"include cycle: %s", strings.Join(cycle, " -> "),
+				)
+			}
+		}
+
+		loaded, err := h.loadIncluded(file)
+		if err != nil {
+			return nil, err
+		}
+
+		included, err := h.include(loaded, filepath.Dir(file), append(chain, file))
+		if err != nil {
+			return nil, err
+		}
+
+		result, err = merger.merge(result, included, []string{})
+		if err != nil {
+			return nil, fmt.Errorf("cannot merge %s: %w", file, err)
+		}
+	}
+
+	return merger.merge(result, m, []string{})
+}
+
+// includedFiles returns absolute names of files listed in include
+// directive, with globs expanded.
+func includedFiles(directive interface{}, dir string) ([]string, error) {
+	var patterns []string
+	switch typed := directive.(type) {
+	case string:
+		patterns = []string{typed}
+	default:
+		slice, ok := asSlice(directive)
+		if !ok {
+			return nil, fmt.Errorf(
+				"include directive must be string or list, got %s",
+				valueKind(directive),
+			)
+		}
+		for _, elem := range toInterfaceSlice(slice) {
+			pattern, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf(
+					"include directive must list strings, got %s",
+					valueKind(elem),
+				)
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+
+		// plain file name must exist, while glob may match nothing
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			matches = []string{pattern}
+		}
+
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	return files, nil
+}
+
+// loadIncluded reads included file into map.
+func (h Hash) loadIncluded(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := h.emptyCopy()
+	if f, ok := FormatForFile(file); ok {
+		loaded.setFormat(f)
+	} else if loaded.unmarshal == nil {
+		f, ok := DetectFormat(data)
+		if !ok {
+			return nil, fmt.Errorf("cannot detect format of %s", file)
+		}
+		loaded.setFormat(f)
+	}
+
+	if err := loaded.ReadHash(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", file, err)
+	}
+
+	return loaded.data, nil
+}
@@ -0,0 +1,108 @@
+package zhash
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadFileIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.json": `{
+			"include": ["base.json", "conf.d/*.json", "empty.d/*.json"],
+			"name": "main",
+			"db": {"include": "db/defaults.conf", "host": "db.example.com"}
+		}`,
+		"base.json":           `{"name": "base", "port": 80, "tags": ["base"]}`,
+		"conf.d/10-port.json": `{"port": 8080}`,
+		"conf.d/20-tags.json": `{"tags": ["extra"], "include": "../extra.json"}`,
+		"extra.json":          `{"extra": true}`,
+		"db/defaults.conf":    `{"host": "localhost", "pool": 10}`,
+	})
+
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	h.SetIncludeKey("include")
+	if err := h.ReadFile(filepath.Join(dir, "main.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"name":  "main",
+		"port":  8080.0,
+		"tags":  []interface{}{"extra"},
+		"extra": true,
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"pool": 10.0,
+		},
+	}
+	if !reflect.DeepEqual(h.GetRoot(), expected) {
+		t.Errorf("ReadFile()=%#v; want %#v", h.GetRoot(), expected)
+	}
+}
+
+func TestReadFileIncludesDisabled(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.json": `{"include": "missing.json"}`,
+	})
+
+	h := NewHash()
+	if err := h.ReadFile(filepath.Join(dir, "main.json")); err != nil {
+		t.Fatal(err)
+	}
+	if h.Get("include") != "missing.json" {
+		t.Errorf("include key is processed: %#v", h.GetRoot())
+	}
+}
+
+func TestReadFileIncludesFail(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"cycle.json":     `{"include": "sub/a.json"}`,
+		"sub/a.json":     `{"include": "b.json"}`,
+		"sub/b.json":     `{"include": "../cycle.json"}`,
+		"missing.json":   `{"include": "nothing.json"}`,
+		"broken.json":    `{"include": "corrupted.json"}`,
+		"invalid.json":   `{"include": 1}`,
+		"corrupted.json": `{"a": `,
+	})
+
+	tests := map[string]string{
+		"cycle.json": "include cycle: " + strings.Join([]string{
+			filepath.Join(dir, "cycle.json"),
+			filepath.Join(dir, "sub", "a.json"),
+			filepath.Join(dir, "sub", "b.json"),
+			filepath.Join(dir, "cycle.json"),
+		}, " -> "),
+		"missing.json": "nothing.json",
+		"broken.json":  "cannot read " + filepath.Join(dir, "corrupted.json"),
+		"invalid.json": "include directive must be string or list, got number",
+	}
+
+	for name, message := range tests {
+		h := NewHash()
+		h.SetIncludeKey("include")
+		err := h.ReadFile(filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("ReadFile(%s)=%v; want error containing %q", name, err, message)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package zhash
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Enables interpolation of references inside string values. When enabled,
+// Get, typed getters, Decode and Validate resolve references lazily, while
+// Set, Merge, WriteHash and friends keep values as is:
+//
+//	${db.host}        value under path db.host, in ParsePath syntax
+//	${env:HOME}       environment variable
+//	${db.port:-5432}  fallback used if value or variable is missing or empty
+//	$${literal}       escaped, gives "${literal}"
+//
+// String consisting of a single reference gives referenced value with its
+// type, so "${db.port}" may give int. Literal fallback of such reference to
+// hash value gets type inferred like LoadEnv does, so "${db.port:-5432}"
+// gives int either way, while fallbacks of environment variables stay
+// strings. Otherwise referenced values are formatted into string, and maps or
+// slices can not be used. Referenced values and fallbacks may contain
+// references too, reference cycles are reported as errors naming the cycle.
+//
+// Maps and slices are returned as resolved copies, so changes of values
+// returned by GetMap, GetHash or GetSlice don't affect h.
+func (h *Hash) SetInterpolation(enabled bool) {
+	h.interpolate = enabled
+}
+
+// Resolves all references in place, see SetInterpolation. Nothing is
+// changed if any reference can not be resolved. Literal "${" left in values,
+// like ones given by "$${" escapes, is stored escaped again, so resolving
+// the hash once more gives the same values.
+func (h Hash) Resolve() error {
+	r := resolver{h: h}
+	resolved, err := r.resolve(h.data, []string{})
+	if err != nil {
+		return err
+	}
+
+	h.replaceRoot(escapeReferences(resolved).(map[string]interface{}))
+	return nil
+}
+
+// escapeReferences returns copy of value with "${" in strings escaped.
+func escapeReferences(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return strings.ReplaceAll(typed, "${", "$${")
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, val := range typed {
+			result[key] = escapeReferences(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, val := range typed {
+			result[i] = escapeReferences(val)
+		}
+		return result
+	case []string:
+		result := make([]string, len(typed))
+		for i, val := range typed {
+			result[i] = escapeReferences(val).(string)
+		}
+		return result
+	}
+	return value
+}
+
+// raw returns h with interpolation disabled, for code which changes values.
+func (h Hash) raw() Hash {
+	h.interpolate = false
+	return h
+}
+
+// getValue returns value under path, resolved if interpolation is enabled.
+func (h Hash) getValue(path []string) (interface{}, error) {
+	value := h.getRaw(path)
+	if !h.interpolate || value == nil {
+		return value, nil
+	}
+
+	r := resolver{h: h}
+	return r.resolve(value, path)
+}
+
+// rootValue returns root map, resolved if interpolation is enabled.
+func (h Hash) rootValue() (map[string]interface{}, error) {
+	if !h.interpolate {
+		return h.data, nil
+	}
+
+	r := resolver{h: h}
+	resolved, err := r.resolve(h.data, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+type resolver struct {
+	h     Hash
+	stack []string
+}
+
+func (r *resolver) resolve(value interface{}, path []string) (interface{}, error) {
+	key := FormatPath(path)
+	for i, visited := range r.stack {
+		if visited == key {
+			chain := append(append([]string{}, r.stack[i:]...), key)
+			return nil, fmt.Errorf(
+				"reference cycle: %s", strings.Join(chain, " -> "),
+			)
+		}
+	}
+
+	r.stack = append(r.stack, key)
+	defer func() {
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	switch typed := value.(type) {
+	case string:
+		return r.resolveString(typed, path)
+
+	case map[string]interface{}, map[interface{}]interface{}:
+		m, _ := toStringMap(typed)
+		result := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			resolved, err := r.resolve(val, append(path, k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = resolved
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, val := range typed {
+			resolved, err := r.resolve(val, append(path, fmt.Sprint(i)))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+
+	case []string:
+		result := make([]string, len(typed))
+		for i, val := range typed {
+			resolved, err := r.interpolate(val, append(path, fmt.Sprint(i)))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+
+	return copyValue(value), nil
+}
+
+// resolveString resolves references in s. String consisting of a single
+// reference gives referenced value as is.
+func (r *resolver) resolveString(s string, path []string) (interface{}, error) {
+	if strings.HasPrefix(s, "${") {
+		end, err := referenceEnd(s, 2)
+		if err != nil {
+			return nil, r.fail(path, err)
+		}
+		if end == len(s)-1 {
+			return r.reference(s[2:end], path, true)
+		}
+	}
+
+	return r.interpolate(s, path)
+}
+
+// interpolate resolves references in s, formatting values into string.
+func (r *resolver) interpolate(s string, path []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			buf.WriteString("${")
+			i += 2
+
+		case strings.HasPrefix(s[i:], "${"):
+			end, err := referenceEnd(s, i+2)
+			if err != nil {
+				return "", r.fail(path, err)
+			}
+
+			value, err := r.reference(s[i+2:end], path, false)
+			if err != nil {
+				return "", err
+			}
+
+			if kind := valueKind(value); kind == "object" || kind == "array" {
+				return "", r.fail(path, fmt.Errorf(
+					"cannot interpolate %s of %s into string", kind, s[i:end+1],
+				))
+			}
+
+			fmt.Fprint(&buf, value)
+			i = end
+
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// referenceEnd returns index of '}' closing reference which starts at start,
+// skipping nested references.
+func referenceEnd(s string, start int) (int, error) {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated reference %q", s[start-2:])
+}
+
+// splitFallback splits reference into name and fallback separated by ":-"
+// outside of nested references.
+func splitFallback(ref string) (string, string, bool) {
+	depth := 0
+	for i := 0; i < len(ref); i++ {
+		switch {
+		case strings.HasPrefix(ref[i:], "${"):
+			depth++
+			i++
+		case ref[i] == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(ref[i:], ":-"):
+			return ref[:i], ref[i+2:], true
+		}
+	}
+	return ref, "", false
+}
+
+// reference resolves single reference found in value under path. Whole is
+// true if the reference is the whole value, so its type matters.
+func (r *resolver) reference(
+	ref string, path []string, whole bool,
+) (interface{}, error) {
+	name, fallback, hasFallback := splitFallback(ref)
+	isEnv := strings.HasPrefix(name, "env:")
+
+	var (
+		value interface{}
+		err   error
+	)
+	if env, ok := cutPrefix(name, "env:"); ok {
+		if val := os.Getenv(env); val != "" {
+			value = val
+		} else if !hasFallback {
+			err = fmt.Errorf("environment variable %s is not set", env)
+		}
+	} else {
+		var refPath []string
+		refPath, err = ParsePath(name)
+		if err != nil {
+			return nil, r.fail(path, err)
+		}
+
+		raw := r.h.getRaw(refPath)
+		switch {
+		case raw != nil && (raw != "" || !hasFallback):
+			return r.resolve(raw, refPath)
+		case !hasFallback:
+			err = notFoundError{refPath}
+		}
+	}
+
+	if err != nil {
+		return nil, r.fail(path, fmt.Errorf("cannot resolve ${%s}: %w", ref, err))
+	}
+	if value != nil {
+		return value, nil
+	}
+
+	// environment gives strings only, while hash values have types
+	if whole && !isEnv && !strings.Contains(fallback, "${") {
+		return inferValue(fallback, ""), nil
+	}
+	return r.resolveString(fallback, path)
+}
+
+func (r *resolver) fail(path []string, err error) error {
+	return &FieldError{Path: append([]string{}, path...), Err: err}
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
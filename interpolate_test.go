@@ -0,0 +1,199 @@
+package zhash
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func interpolationBase() Hash {
+	h := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+			"url":  "postgres://${db.host}:${db.port}/${db.name:-app}",
+		},
+		"port":     "${db.port}",
+		"data":     "${env:ZHASH_TEST_HOME}/data",
+		"fallback": "${env:ZHASH_TEST_MISSING:-${db.host}}",
+		"escaped":  "$${db.host} is ${db.host}",
+		"servers":  []interface{}{"${db.host}", "${servers.0}:${db.port}"},
+		"names":    []string{"${db.host}"},
+		"chain":    "${url}",
+		"url":      "${db.url}",
+		"empty":    "",
+		"defaults": "${empty:-none}",
+	})
+	h.SetInterpolation(true)
+	return h
+}
+
+func TestInterpolation(t *testing.T) {
+	os.Setenv("ZHASH_TEST_HOME", "/home/test")
+	defer os.Unsetenv("ZHASH_TEST_HOME")
+
+	h := interpolationBase()
+
+	tests := []struct {
+		path  string
+		value interface{}
+	}{
+		{"db.url", "postgres://localhost:5432/app"},
+		{"port", 5432},
+		{"data", "/home/test/data"},
+		{"fallback", "localhost"},
+		{"escaped", "${db.host} is localhost"},
+		{"servers", []interface{}{"localhost", "localhost:5432"}},
+		{"servers[1]", "localhost:5432"},
+		{"names", []string{"localhost"}},
+		{"chain", "postgres://localhost:5432/app"},
+		{"defaults", "none"},
+	}
+
+	for _, test := range tests {
+		if value := h.GetP(test.path); !reflect.DeepEqual(value, test.value) {
+			t.Errorf("GetP(%q)=%#v; want %#v", test.path, value, test.value)
+		}
+	}
+
+	if port, err := h.GetInt("port"); port != 5432 || err != nil {
+		t.Errorf("GetInt(port)=%v, %v", port, err)
+	}
+
+	var cfg struct {
+		URL string `zhash:"url"`
+	}
+	if err := h.Decode(&cfg, "db"); err != nil || cfg.URL != "postgres://localhost:5432/app" {
+		t.Errorf("Decode()=%#v, %v", cfg, err)
+	}
+
+	// raw values are kept
+	h.AppendStringSlice("x", "names")
+	if names := h.raw().Get("names"); !reflect.DeepEqual(names, []string{"${db.host}", "x"}) {
+		t.Errorf("raw names after append=%#v", names)
+	}
+	if s := h.String(); !strings.Contains(s, "${db.host}") {
+		t.Errorf("String() contains resolved values: %s", s)
+	}
+}
+
+func TestInterpolationFallbackType(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"db":      map[string]interface{}{"host": "localhost"},
+		"port":    "${db.port:-5432}",
+		"ratio":   "${db.ratio:-0.5}",
+		"zip":     "${db.zip:-007}",
+		"url":     "${db.host}:${db.port:-5432.0}",
+		"env":     "${env:ZHASH_TEST_MISSING:-8080}",
+		"chained": "${db.port:-${db.host}}",
+	})
+	h.SetInterpolation(true)
+
+	tests := []struct {
+		path  string
+		value interface{}
+	}{
+		{"port", 5432},
+		{"ratio", 0.5},
+		{"zip", "007"},
+		{"url", "localhost:5432.0"},
+		{"env", "8080"},
+		{"chained", "localhost"},
+	}
+
+	for _, test := range tests {
+		if value := h.GetP(test.path); !reflect.DeepEqual(value, test.value) {
+			t.Errorf("GetP(%q)=%#v; want %#v", test.path, value, test.value)
+		}
+	}
+
+	if port, err := h.GetInt("port"); port != 5432 || err != nil {
+		t.Errorf("GetInt(port)=%v, %v", port, err)
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"a":         "${b}",
+		"b":         "x${c}",
+		"c":         "${a}",
+		"self":      map[string]interface{}{"ref": "${self}"},
+		"missing":   "${nothing}",
+		"env":       "${env:ZHASH_TEST_MISSING}",
+		"broken":    "${a",
+		"container": "x${map}",
+		"map":       map[string]interface{}{"key": 1},
+		"badpath":   "${a..b}",
+	})
+	h.SetInterpolation(true)
+
+	tests := map[string]string{
+		"a":         "reference cycle: a -> b -> c -> a",
+		"self":      "reference cycle: self -> self.ref -> self",
+		"missing":   "value for nothing not found",
+		"env":       "environment variable ZHASH_TEST_MISSING is not set",
+		"broken":    "unterminated reference",
+		"container": "cannot interpolate object of ${map} into string",
+		"badpath":   "invalid path",
+	}
+
+	for path, message := range tests {
+		_, err := h.GetString(path)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("GetString(%s) error=%v; want %q", path, err, message)
+		}
+		if h.Get(path) != nil {
+			t.Errorf("Get(%s)=%#v; want nil", path, h.Get(path))
+		}
+	}
+
+	if err := h.Resolve(); err == nil {
+		t.Error("Resolve() does not fail")
+	}
+	if h.raw().Get("a") != "${b}" {
+		t.Error("failed Resolve() changed hash")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"host":    "localhost",
+		"url":     "http://${host}/",
+		"list":    []interface{}{"${host}"},
+		"escaped": "$${host}",
+		"names":   []string{"$${host} is ${host}"},
+	})
+
+	if err := h.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"host":    "localhost",
+		"url":     "http://localhost/",
+		"list":    []interface{}{"localhost"},
+		"escaped": "$${host}",
+		"names":   []string{"$${host} is localhost"},
+	}
+	if !reflect.DeepEqual(h.GetRoot(), expected) {
+		t.Errorf("Resolve()=%#v; want %#v", h.GetRoot(), expected)
+	}
+
+	h.SetInterpolation(true)
+	if escaped := h.Get("escaped"); escaped != "${host}" {
+		t.Errorf("Get(escaped) after Resolve()=%#v; want ${host}", escaped)
+	}
+	if err := h.Resolve(); err != nil || !reflect.DeepEqual(h.GetRoot(), expected) {
+		t.Errorf("second Resolve()=%#v, %v; want %#v", h.GetRoot(), err, expected)
+	}
+}
+
+func TestInterpolationDisabled(t *testing.T) {
+	h := interpolationBase()
+	h.SetInterpolation(false)
+
+	if port := h.Get("port"); port != "${db.port}" {
+		t.Errorf("Get(port)=%#v; want raw value", port)
+	}
+}
@@ -32,8 +32,15 @@ func (h *Hash) ReadHash(r io.Reader) error {
 		return err
 	}
 
-	err = h.unmarshal(b, &h.data)
-	return err
+	return h.notifyChange(nil, func() error {
+		if err := h.unmarshal(b, &h.data); err != nil {
+			return err
+		}
+		if h.normalize {
+			h.Normalize()
+		}
+		return nil
+	})
 }
 
 // Mashall hash using supplied Marshaller function and writes it to w
@@ -0,0 +1,280 @@
+package zhash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layers is an ordered stack of named hashes, for example defaults, config
+// file, environment and command line flags. Each value is resolved against
+// the topmost layer which defines it, and Explain tells where it came from.
+type Layers struct {
+	layers []layer
+}
+
+type layer struct {
+	name string
+	hash Hash
+}
+
+func NewLayers() *Layers {
+	return &Layers{}
+}
+
+// Adds layer on top of existing ones, so its values take precedence over
+// values of all layers added before. If layer with such name exists already,
+// its hash is replaced keeping its position.
+//
+//	layers.Add("defaults", defaults)
+//	layers.Add("file", file)
+//	layers.Add("env", env)
+func (l *Layers) Add(name string, h Hash) {
+	for i := range l.layers {
+		if l.layers[i].name == name {
+			l.layers[i].hash = h
+			return
+		}
+	}
+
+	l.layers = append(l.layers, layer{name, h})
+}
+
+// Returns layer names from the lowest to the highest priority.
+func (l *Layers) Names() []string {
+	names := make([]string, len(l.layers))
+	for i, layer := range l.layers {
+		names[i] = layer.name
+	}
+	return names
+}
+
+// Returns hash of layer with given name.
+func (l *Layers) Layer(name string) (Hash, bool) {
+	for _, layer := range l.layers {
+		if layer.name == name {
+			return layer.hash, true
+		}
+	}
+	return Hash{}, false
+}
+
+// lookup returns the topmost layer defining value under the path.
+func (l *Layers) lookup(path []string) (layer, bool) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if l.layers[i].hash.Get(path...) != nil {
+			return l.layers[i], true
+		}
+	}
+	return layer{}, false
+}
+
+// mapLayers returns layers whose maps under the path make the value seen
+// through Layers, from the highest priority: the topmost layer defining the
+// value, if it is a map, and layers below it down to the first one defining
+// something else than a map.
+func (l *Layers) mapLayers(path []string) []layer {
+	var result []layer
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		value := l.layers[i].hash.Get(path...)
+		if value == nil {
+			continue
+		}
+		if _, ok := toStringMap(value); !ok {
+			break
+		}
+		result = append(result, l.layers[i])
+	}
+	return result
+}
+
+// Retrieves value from the topmost layer defining it, returns nil if no
+// layer does. Maps are merged from all layers like GetHash does.
+func (l *Layers) Get(path ...string) interface{} {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return nil
+	}
+
+	value := layer.hash.Get(path...)
+	if _, ok := toStringMap(value); ok {
+		if merged, err := l.GetHash(path...); err == nil {
+			return merged.GetRoot()
+		}
+	}
+	return value
+}
+
+func (l *Layers) GetString(path ...string) (string, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return "", notFoundError{path}
+	}
+	return layer.hash.GetString(path...)
+}
+
+func (l *Layers) GetBool(path ...string) (bool, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return false, notFoundError{path}
+	}
+	return layer.hash.GetBool(path...)
+}
+
+func (l *Layers) GetInt(path ...string) (int64, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return 0, notFoundError{path}
+	}
+	return layer.hash.GetInt(path...)
+}
+
+func (l *Layers) GetFloat(path ...string) (float64, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return 0, notFoundError{path}
+	}
+	return layer.hash.GetFloat(path...)
+}
+
+func (l *Layers) GetSlice(path ...string) ([]interface{}, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return []interface{}{}, notFoundError{path}
+	}
+	return layer.hash.GetSlice(path...)
+}
+
+func (l *Layers) GetIntSlice(path ...string) ([]int64, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return []int64{}, notFoundError{path}
+	}
+	return layer.hash.GetIntSlice(path...)
+}
+
+func (l *Layers) GetFloatSlice(path ...string) ([]float64, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return []float64{}, notFoundError{path}
+	}
+	return layer.hash.GetFloatSlice(path...)
+}
+
+func (l *Layers) GetStringSlice(path ...string) ([]string, error) {
+	layer, ok := l.lookup(path)
+	if !ok {
+		return []string{}, notFoundError{path}
+	}
+	return layer.hash.GetStringSlice(path...)
+}
+
+// Retrieves map under the path merged from all layers defining it, so
+// h.GetHash("db") contains both keys from defaults and overrides. Layers
+// below one defining something else than a map there are shadowed by it.
+// Returned hash is a copy.
+func (l *Layers) GetHash(path ...string) (Hash, error) {
+	top, ok := l.lookup(path)
+	if !ok {
+		return NewHash(), notFoundError{path}
+	}
+	if _, err := top.hash.GetHash(path...); err != nil {
+		return NewHash(), err
+	}
+
+	result := NewHash()
+	sources := l.mapLayers(path)
+	for i := len(sources) - 1; i >= 0; i-- {
+		sub, err := sources[i].hash.GetHash(path...)
+		if err != nil {
+			return NewHash(), err
+		}
+		if err := result.Merge(sub); err != nil {
+			return NewHash(), err
+		}
+	}
+	return result, nil
+}
+
+// Merges all layers into single hash, from the lowest to the highest
+// priority.
+func (l *Layers) Flatten(opts ...MergeOption) (Hash, error) {
+	result := NewHash()
+	for _, layer := range l.layers {
+		if err := result.Merge(layer.hash, opts...); err != nil {
+			return NewHash(), fmt.Errorf("layer %s: %w", layer.name, err)
+		}
+	}
+	return result, nil
+}
+
+// Explanation describes how value was resolved by Layers.
+type Explanation struct {
+	Path []string
+	// Name of the layer value was taken from, empty if no layer defines it
+	Winner string
+	// Names of layers value was taken from, from the highest priority. Maps
+	// are merged from several layers, other values come from Winner only
+	Sources []string
+	// Values of all layers, from the highest to the lowest priority
+	Values []LayerValue
+}
+
+type LayerValue struct {
+	Layer   string
+	Value   interface{}
+	Defined bool
+}
+
+// Explains which layer value under the path is taken from, and what each
+// layer holds there.
+func (l *Layers) Explain(path ...string) Explanation {
+	explanation := Explanation{Path: path}
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		value := l.layers[i].hash.Get(path...)
+		explanation.Values = append(explanation.Values, LayerValue{
+			Layer:   l.layers[i].name,
+			Value:   value,
+			Defined: value != nil,
+		})
+		if value != nil && explanation.Winner == "" {
+			explanation.Winner = l.layers[i].name
+		}
+	}
+
+	for _, source := range l.mapLayers(path) {
+		explanation.Sources = append(explanation.Sources, source.name)
+	}
+	if len(explanation.Sources) == 0 && explanation.Winner != "" {
+		explanation.Sources = []string{explanation.Winner}
+	}
+	return explanation
+}
+
+func (e Explanation) String() string {
+	var buf strings.Builder
+	if e.Winner == "" {
+		fmt.Fprintf(&buf, "%s: not defined\n", strings.Join(e.Path, "."))
+	} else {
+		fmt.Fprintf(
+			&buf, "%s: from %s\n",
+			strings.Join(e.Path, "."), strings.Join(e.Sources, ", "),
+		)
+	}
+
+	used := map[string]bool{}
+	for _, source := range e.Sources {
+		used[source] = true
+	}
+	for _, value := range e.Values {
+		switch {
+		case !value.Defined:
+			fmt.Fprintf(&buf, "  %s: not defined\n", value.Layer)
+		case used[value.Layer]:
+			fmt.Fprintf(&buf, "  %s: %#v (used)\n", value.Layer, value.Value)
+		default:
+			fmt.Fprintf(&buf, "  %s: %#v\n", value.Layer, value.Value)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,145 @@
+package zhash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testLayers() *Layers {
+	layers := NewLayers()
+	layers.Add("defaults", HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"debug": false,
+	}))
+	layers.Add("file", HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+		},
+	}))
+	layers.Add("flags", HashFromMap(map[string]interface{}{
+		"debug": true,
+	}))
+	return layers
+}
+
+func TestLayersGet(t *testing.T) {
+	layers := testLayers()
+
+	host, err := layers.GetString("db", "host")
+	if err != nil || host != "db.example.com" {
+		t.Errorf("GetString(db, host)=%q, %v", host, err)
+	}
+	port, err := layers.GetInt("db", "port")
+	if err != nil || port != 5432 {
+		t.Errorf("GetInt(db, port)=%d, %v", port, err)
+	}
+	debug, err := layers.GetBool("debug")
+	if err != nil || !debug {
+		t.Errorf("GetBool(debug)=%v, %v", debug, err)
+	}
+	if _, err := layers.GetString("missing"); !IsNotFound(err) {
+		t.Errorf("GetString(missing) returned %v", err)
+	}
+	if v := layers.Get("db", "port"); v != 5432 {
+		t.Errorf("Get(db, port)=%#v", v)
+	}
+
+	db, err := layers.GetHash("db")
+	expected := map[string]interface{}{"host": "db.example.com", "port": 5432}
+	if err != nil || !reflect.DeepEqual(db.GetRoot(), expected) {
+		t.Errorf("GetHash(db)=%s, %v", db, err)
+	}
+
+	if !reflect.DeepEqual(layers.Names(), []string{"defaults", "file", "flags"}) {
+		t.Errorf("Names()=%v", layers.Names())
+	}
+
+	layers.Add("file", NewHash())
+	host, _ = layers.GetString("db", "host")
+	if host != "localhost" || len(layers.Names()) != 3 {
+		t.Errorf("Add with existing name doesn't replace layer")
+	}
+}
+
+func TestLayersExplain(t *testing.T) {
+	layers := testLayers()
+
+	explanation := layers.Explain("db", "host")
+	if explanation.Winner != "file" {
+		t.Errorf("Explain(db, host).Winner=%q; want file", explanation.Winner)
+	}
+
+	expected := []LayerValue{
+		{"flags", nil, false},
+		{"file", "db.example.com", true},
+		{"defaults", "localhost", true},
+	}
+	if !reflect.DeepEqual(explanation.Values, expected) {
+		t.Errorf("Explain(db, host).Values=%#v", explanation.Values)
+	}
+	if !strings.Contains(explanation.String(), "from file") {
+		t.Errorf("Explain(db, host).String()=%q", explanation.String())
+	}
+
+	if layers.Explain("missing").Winner != "" {
+		t.Errorf("Explain(missing) has winner")
+	}
+}
+
+func TestLayersMergedMaps(t *testing.T) {
+	layers := testLayers()
+	layers.Add("env", HashFromMap(map[string]interface{}{
+		"debug": map[string]interface{}{"level": 2},
+	}))
+
+	expected := map[string]interface{}{"host": "db.example.com", "port": 5432}
+	if db := layers.Get("db"); !reflect.DeepEqual(db, expected) {
+		t.Errorf("Get(db)=%#v; want %#v", db, expected)
+	}
+
+	explanation := layers.Explain("db")
+	if !reflect.DeepEqual(explanation.Sources, []string{"file", "defaults"}) {
+		t.Errorf("Explain(db).Sources=%q; want file, defaults", explanation.Sources)
+	}
+	if !strings.Contains(explanation.String(), "from file, defaults") {
+		t.Errorf("Explain(db).String()=%q", explanation.String())
+	}
+
+	// flags shadow defaults
+	expected = map[string]interface{}{"level": 2}
+	if debug := layers.Get("debug"); !reflect.DeepEqual(debug, expected) {
+		t.Errorf("Get(debug)=%#v; want %#v", debug, expected)
+	}
+	if debug, err := layers.GetHash("debug"); err != nil ||
+		!reflect.DeepEqual(debug.GetRoot(), expected) {
+		t.Errorf("GetHash(debug)=%s, %v; want %#v", debug, err, expected)
+	}
+	if sources := layers.Explain("debug").Sources; !reflect.DeepEqual(sources, []string{"env"}) {
+		t.Errorf("Explain(debug).Sources=%q; want env", sources)
+	}
+	if sources := layers.Explain("db", "host").Sources; !reflect.DeepEqual(sources, []string{"file"}) {
+		t.Errorf("Explain(db, host).Sources=%q; want file", sources)
+	}
+}
+
+func TestLayersFlatten(t *testing.T) {
+	flat, err := testLayers().Flatten()
+	if err != nil {
+		t.Fatalf("Flatten caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432,
+		},
+		"debug": true,
+	}
+	if !reflect.DeepEqual(flat.GetRoot(), expected) {
+		t.Errorf("Flatten()=%#v", flat.GetRoot())
+	}
+}
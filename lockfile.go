@@ -0,0 +1,228 @@
+package zhash
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var errLockUnsupported = errors.New("file locking is not supported")
+
+// ModifiedError is returned by LockedFile.Save when file was changed by
+// someone else since it was loaded.
+type ModifiedError struct {
+	Path string
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("%s was modified since it was loaded", e.Path)
+}
+
+// Check if given err is *ModifiedError returned by LockedFile.Save.
+func IsModified(err error) bool {
+	var modified *ModifiedError
+	return errors.As(err, &modified)
+}
+
+// LockedFile is a hash loaded from file for load-modify-save cycle, which
+// protects the file from concurrent editors. Lock is advisory flock taken on
+// a separate path.lock file, as the file itself is replaced on save, so
+// only tools using LockedFile are excluded.
+//
+//	f, err := zhash.OpenLocked("config.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	f.Hash().Set(8080, "server", "port")
+//	err = f.Save()
+type LockedFile struct {
+	path string
+	hash Hash
+	lock *os.File
+
+	exists bool
+	size   int64
+	sum    [sha256.Size]byte
+}
+
+// Takes exclusive lock of file, waiting for other holders to close it, and
+// loads hash from it. Missing file gives an empty hash. Lock is held until
+// Close.
+func OpenLocked(path string) (*LockedFile, error) {
+	lock, err := acquireLock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &LockedFile{path: path, lock: lock}
+	if err := f.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Loads hash from file without holding a lock. Lock is taken by Save only,
+// which fails with *ModifiedError if file was changed since it was loaded.
+func OpenOptimistic(path string) (*LockedFile, error) {
+	f := &LockedFile{path: path}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func acquireLock(path string) (*os.File, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("cannot lock %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+func (f *LockedFile) load() error {
+	f.hash = NewHash()
+
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		if format, ok := FormatForFile(f.path); ok {
+			f.hash.setFormat(format)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// size and sum are both taken from what was read, as the file may be
+	// replaced in between of separate calls
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	if err := f.hash.readFileData(f.path, data); err != nil {
+		return err
+	}
+
+	f.remember(data)
+	return nil
+}
+
+// remember records state of file having given content.
+func (f *LockedFile) remember(data []byte) {
+	f.exists = true
+	f.size = int64(len(data))
+	f.sum = sha256.Sum256(data)
+}
+
+// Returns loaded hash. Changes of it are written by Save.
+func (f *LockedFile) Hash() Hash {
+	return f.hash
+}
+
+// Returns path of file.
+func (f *LockedFile) Path() string {
+	return f.path
+}
+
+// Writes hash back to file by WriteFileAtomic, using format of the file.
+// Returns *ModifiedError and writes nothing if file was changed since it was
+// loaded or saved.
+func (f *LockedFile) Save(opts ...WriteOption) error {
+	if f.lock == nil {
+		lock, err := acquireLock(f.path)
+		if err != nil && !errors.Is(err, errLockUnsupported) {
+			return err
+		}
+		if lock != nil {
+			defer releaseLock(lock)
+		}
+	}
+
+	if err := f.checkUnmodified(); err != nil {
+		return err
+	}
+
+	config := writeConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	data, err := f.hash.marshalFor(f.path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(f.path, data, 0644, config); err != nil {
+		return err
+	}
+
+	f.remember(data)
+	return nil
+}
+
+// checkUnmodified compares content of file with the remembered one. Size
+// differing means the file was changed, otherwise content hashes are
+// compared, as mtime has coarse resolution on some file systems and changes
+// on touch.
+func (f *LockedFile) checkUnmodified() error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) && !f.exists {
+		return nil
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ModifiedError{f.path}
+		}
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !f.exists || info.Size() != f.size {
+		return &ModifiedError{f.path}
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(data) != f.sum {
+		return &ModifiedError{f.path}
+	}
+	return nil
+}
+
+// Releases the lock. Hash stays usable, and Save works like for optimistic
+// files after Close.
+func (f *LockedFile) Close() error {
+	if f.lock == nil {
+		return nil
+	}
+
+	err := releaseLock(f.lock)
+	f.lock = nil
+	return err
+}
+
+func releaseLock(lock *os.File) error {
+	unlockErr := unlockFile(lock)
+	if err := lock.Close(); err != nil {
+		return err
+	}
+	return unlockErr
+}
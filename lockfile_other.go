@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package zhash
+
+import "os"
+
+func lockFile(f *os.File) error {
+	return errLockUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
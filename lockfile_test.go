@@ -0,0 +1,161 @@
+package zhash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": 80}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := OpenLocked(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened := make(chan *LockedFile)
+	go func() {
+		second, err := OpenLocked(path)
+		if err != nil {
+			t.Error(err)
+		}
+		opened <- second
+	}()
+
+	select {
+	case <-opened:
+		t.Fatal("second OpenLocked does not wait for the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.Hash().Set(81, "port")
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+
+	var second *LockedFile
+	select {
+	case second = <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second OpenLocked is not unlocked by Close")
+	}
+	defer second.Close()
+
+	if port := second.Hash().Get("port"); port != 81.0 {
+		t.Errorf("port=%v; want 81", port)
+	}
+
+	second.Hash().Set(82, "port")
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode=%v; want 0600", info.Mode().Perm())
+	}
+}
+
+func TestLockedFileOptimistic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": 80}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := OpenOptimistic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenOptimistic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// touching does not change content
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(path, future, future)
+
+	first.Hash().Set(81, "port")
+	if err := first.Save(); err != nil {
+		t.Fatalf("Save() of touched file: %v", err)
+	}
+
+	second.Hash().Set(82, "port")
+	err = second.Save()
+	if !IsModified(err) {
+		t.Fatalf("Save() of modified file=%v; want *ModifiedError", err)
+	}
+	var modified *ModifiedError
+	if !errors.As(err, &modified) || modified.Path != path {
+		t.Errorf("ModifiedError.Path=%v; want %s", modified, path)
+	}
+
+	if content, _ := os.ReadFile(path); string(content) != `{"port":81}` {
+		t.Errorf("content=%s", content)
+	}
+
+	first.Hash().Set(83, "port")
+	if err := first.Save(); err != nil {
+		t.Errorf("second Save(): %v", err)
+	}
+}
+
+func TestLockedFileSameSecondEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": 80}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenOptimistic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// edit keeping size and mtime, like one within mtime resolution
+	if err := os.WriteFile(path, []byte(`{"port": 90}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+
+	f.Hash().Set(81, "port")
+	if err := f.Save(); !IsModified(err) {
+		t.Errorf("Save() of edited file=%v; want *ModifiedError", err)
+	}
+}
+
+func TestLockedFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	first, err := OpenOptimistic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenOptimistic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Hash().Set(1, "a")
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); !IsModified(err) {
+		t.Errorf("Save() of created file=%v; want *ModifiedError", err)
+	}
+
+	os.Remove(path)
+	if err := first.Save(); !IsModified(err) {
+		t.Errorf("Save() of removed file=%v; want *ModifiedError", err)
+	}
+}
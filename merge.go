@@ -0,0 +1,257 @@
+package zhash
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SliceStrategy defines how Merge combines two slices found under the same
+// path.
+type SliceStrategy int
+
+const (
+	// Slice from other hash replaces existing one.
+	SliceReplace SliceStrategy = iota
+	// Elements of slice from other hash are appended to existing ones.
+	SliceAppend
+	// Elements of slice from other hash are appended if existing slice
+	// doesn't contain equal element yet.
+	SliceUnion
+	// Map elements with equal value under key field are merged, others are
+	// appended. Use MergeSlicesByKey option to set key field.
+	SliceMergeByKey
+)
+
+// ScalarStrategy defines how Merge combines two values found under the same
+// path if they are not both maps or both slices.
+type ScalarStrategy int
+
+const (
+	// Value from other hash replaces existing one.
+	ScalarOverride ScalarStrategy = iota
+	// Existing value is kept.
+	ScalarKeep
+	// Merge fails if values differ.
+	ScalarError
+)
+
+// MergeOption configures Merge.
+type MergeOption func(*mergeConfig)
+
+type slicePolicy struct {
+	path     []string
+	strategy SliceStrategy
+	key      string
+}
+
+type scalarPolicy struct {
+	path     []string
+	strategy ScalarStrategy
+}
+
+type mergeConfig struct {
+	slices  []slicePolicy
+	scalars []scalarPolicy
+}
+
+// Sets slice strategy for slices found under the path and its descendants.
+// Without path sets default strategy, which is SliceReplace.
+func MergeSlices(strategy SliceStrategy, path ...string) MergeOption {
+	return func(c *mergeConfig) {
+		c.slices = append(c.slices, slicePolicy{path, strategy, ""})
+	}
+}
+
+// Sets SliceMergeByKey strategy with given key field for slices found under
+// the path and its descendants.
+//
+//	h.Merge(overrides, zhash.MergeSlicesByKey("name", "servers"))
+func MergeSlicesByKey(key string, path ...string) MergeOption {
+	return func(c *mergeConfig) {
+		c.slices = append(c.slices, slicePolicy{path, SliceMergeByKey, key})
+	}
+}
+
+// Sets scalar strategy for values found under the path and its descendants.
+// Without path sets default strategy, which is ScalarOverride.
+func MergeScalars(strategy ScalarStrategy, path ...string) MergeOption {
+	return func(c *mergeConfig) {
+		c.scalars = append(c.scalars, scalarPolicy{path, strategy})
+	}
+}
+
+// Deep merges other hash into h. Nested maps (including yaml
+// map[interface{}]interface{}) are merged recursively, slices and other
+// values are combined according to strategies set by options. Values taken
+// from other are copied, so later changes of other don't affect h. If merge
+// fails h is left untouched.
+func (h Hash) Merge(other Hash, opts ...MergeOption) error {
+	config := &mergeConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// merge works with copy of h, so it can be dropped on error. As a side
+	// effect yaml maps of h are converted to map[string]interface{}
+	merged, err := config.merge(copyValue(h.data), other.data, []string{})
+	if err != nil {
+		return err
+	}
+
+	h.replaceRoot(merged.(map[string]interface{}))
+	return nil
+}
+
+func (c *mergeConfig) merge(
+	dst interface{}, src interface{}, path []string,
+) (interface{}, error) {
+	if dst == nil {
+		return copyValue(src), nil
+	}
+
+	dstMap, dstIsMap := toStringMap(dst)
+	srcMap, srcIsMap := toStringMap(src)
+	if dstIsMap && srcIsMap {
+		for key, val := range srcMap {
+			merged, err := c.merge(dstMap[key], val, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			dstMap[key] = merged
+		}
+		return dstMap, nil
+	}
+
+	dstSlice, dstIsSlice := asSlice(dst)
+	srcSlice, srcIsSlice := asSlice(src)
+	if dstIsSlice && srcIsSlice {
+		policy := c.slicePolicy(path)
+		if policy.strategy == SliceReplace {
+			return copyValue(src), nil
+		}
+		return c.mergeSlices(
+			toInterfaceSlice(dstSlice), toInterfaceSlice(srcSlice),
+			policy, path,
+		)
+	}
+
+	switch c.scalarStrategy(path) {
+	case ScalarKeep:
+		return dst, nil
+	case ScalarError:
+		if !reflect.DeepEqual(dst, src) {
+			return nil, fmt.Errorf(
+				"cannot merge %s, conflicting values %v and %v",
+				strings.Join(path, "."), dst, src,
+			)
+		}
+		return dst, nil
+	}
+
+	return copyValue(src), nil
+}
+
+func (c *mergeConfig) mergeSlices(
+	dst []interface{}, src []interface{}, policy slicePolicy, path []string,
+) (interface{}, error) {
+	switch policy.strategy {
+	case SliceAppend:
+		for _, elem := range src {
+			dst = append(dst, copyValue(elem))
+		}
+		return dst, nil
+
+	case SliceUnion:
+		for _, elem := range src {
+			if !containsValue(dst, elem) {
+				dst = append(dst, copyValue(elem))
+			}
+		}
+		return dst, nil
+
+	case SliceMergeByKey:
+		for _, elem := range src {
+			i := indexByKey(dst, elem, policy.key)
+			if i < 0 {
+				dst = append(dst, copyValue(elem))
+				continue
+			}
+
+			merged, err := c.merge(dst[i], elem, append(path, fmt.Sprint(i)))
+			if err != nil {
+				return nil, err
+			}
+			dst[i] = merged
+		}
+		return dst, nil
+	}
+
+	return nil, fmt.Errorf("unknown slice strategy %d", policy.strategy)
+}
+
+func containsValue(slice []interface{}, value interface{}) bool {
+	for _, elem := range slice {
+		if reflect.DeepEqual(elem, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexByKey returns index of map element in slice having the same value
+// under key as value has, or -1.
+func indexByKey(slice []interface{}, value interface{}, key string) int {
+	m, ok := toStringMap(value)
+	if !ok {
+		return -1
+	}
+	id, ok := m[key]
+	if !ok {
+		return -1
+	}
+
+	for i, elem := range slice {
+		elemMap, ok := toStringMap(elem)
+		if ok && reflect.DeepEqual(elemMap[key], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *mergeConfig) slicePolicy(path []string) slicePolicy {
+	result := slicePolicy{strategy: SliceReplace}
+	matched := -1
+	for _, policy := range c.slices {
+		if len(policy.path) > matched && hasPrefix(path, policy.path) {
+			result = policy
+			matched = len(policy.path)
+		}
+	}
+	return result
+}
+
+func (c *mergeConfig) scalarStrategy(path []string) ScalarStrategy {
+	result := ScalarOverride
+	matched := -1
+	for _, policy := range c.scalars {
+		if len(policy.path) > matched && hasPrefix(path, policy.path) {
+			result = policy.strategy
+			matched = len(policy.path)
+		}
+	}
+	return result
+}
+
+func hasPrefix(path []string, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, p := range prefix {
+		if path[i] != p {
+			return false
+		}
+	}
+	return true
+}
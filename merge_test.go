@@ -0,0 +1,148 @@
+package zhash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	defaults := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"tags":  []interface{}{"a", "b"},
+		"level": "info",
+	})
+
+	overrides := HashFromMap(map[string]interface{}{
+		"db": map[interface{}]interface{}{
+			"host": "db.example.com",
+			"pool": map[interface{}]interface{}{"size": 10},
+		},
+		"tags": []string{"c"},
+	})
+
+	if err := defaults.Merge(overrides); err != nil {
+		t.Fatalf("Merge caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432,
+			"pool": map[string]interface{}{"size": 10},
+		},
+		"tags":  []string{"c"},
+		"level": "info",
+	}
+
+	if !reflect.DeepEqual(defaults.GetRoot(), expected) {
+		t.Errorf("Merge()=%#v; want %#v", defaults.GetRoot(), expected)
+	}
+
+	overrides.Set("changed", "db", "host")
+	if defaults.Get("db", "host") != "db.example.com" {
+		t.Errorf("Merge doesn't copy merged values")
+	}
+}
+
+func TestMergeSliceStrategies(t *testing.T) {
+	base := map[string]interface{}{
+		"append": []interface{}{1, 2},
+		"union":  []interface{}{1, 2},
+		"servers": []interface{}{
+			map[string]interface{}{"name": "a", "port": 1},
+			map[string]interface{}{"name": "b", "port": 2},
+		},
+		"replace": []interface{}{1, 2},
+	}
+	other := map[string]interface{}{
+		"append": []interface{}{2, 3},
+		"union":  []int{2, 3},
+		"servers": []interface{}{
+			map[interface{}]interface{}{"name": "b", "port": 3},
+			map[string]interface{}{"name": "c", "port": 4},
+		},
+		"replace": []interface{}{3},
+	}
+
+	hash := HashFromMap(base)
+	err := hash.Merge(
+		HashFromMap(other),
+		MergeSlices(SliceAppend, "append"),
+		MergeSlices(SliceUnion, "union"),
+		MergeSlicesByKey("name", "servers"),
+	)
+	if err != nil {
+		t.Fatalf("Merge caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"append": []interface{}{1, 2, 2, 3},
+		"union":  []interface{}{1, 2, 3},
+		"servers": []interface{}{
+			map[string]interface{}{"name": "a", "port": 1},
+			map[string]interface{}{"name": "b", "port": 3},
+			map[string]interface{}{"name": "c", "port": 4},
+		},
+		"replace": []interface{}{3},
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("Merge()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestMergeScalarStrategies(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"keep":     map[string]interface{}{"a": 1, "b": 2},
+		"override": map[string]interface{}{"a": 1},
+		"strict":   map[string]interface{}{"a": 1, "b": 2},
+	})
+
+	other := HashFromMap(map[string]interface{}{
+		"keep":     map[string]interface{}{"a": 10, "c": 30},
+		"override": map[string]interface{}{"a": 10},
+		"strict":   map[string]interface{}{"a": 1},
+	})
+
+	err := hash.Merge(
+		other,
+		MergeScalars(ScalarKeep, "keep"),
+		MergeScalars(ScalarError, "strict"),
+	)
+	if err != nil {
+		t.Fatalf("Merge caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"keep":     map[string]interface{}{"a": 1, "b": 2, "c": 30},
+		"override": map[string]interface{}{"a": 10},
+		"strict":   map[string]interface{}{"a": 1, "b": 2},
+	}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("Merge()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	conflicting := HashFromMap(map[string]interface{}{
+		"override": map[string]interface{}{"a": 20},
+		"strict":   map[string]interface{}{"b": 3},
+	})
+	err = hash.Merge(conflicting, MergeScalars(ScalarError))
+	if err == nil {
+		t.Errorf("Merge with ScalarError and conflicting values succeeded")
+	}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("failed Merge changed hash: %#v", hash.GetRoot())
+	}
+
+	err = hash.Merge(conflicting,
+		MergeScalars(ScalarError),
+		MergeScalars(ScalarOverride, "override"),
+		MergeScalars(ScalarKeep, "strict", "b"),
+	)
+	if err != nil {
+		t.Errorf("Merge with more specific strategies caused error: %v", err)
+	}
+}
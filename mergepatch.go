@@ -0,0 +1,74 @@
+package zhash
+
+// Applies RFC 7396 JSON Merge Patch to h: nil values of patch delete keys,
+// maps are merged recursively and any other value replaces existing one.
+func (h Hash) MergePatch(patch Hash) {
+	h.notifyChange(nil, func() error {
+		mergePatch(h.data, patch.data)
+		return nil
+	})
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := toStringMap(patch)
+	if !ok {
+		return copyValue(patch)
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap, ok = toStringMap(target)
+		if !ok {
+			targetMap = map[string]interface{}{}
+		}
+	}
+
+	for key, val := range patchMap {
+		if val == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = mergePatch(targetMap[key], val)
+	}
+
+	return targetMap
+}
+
+// Creates RFC 7396 JSON Merge Patch which turns from into to. As merge
+// patch uses nil for deletion, nil values of to can not be represented and
+// are treated as missing keys.
+func CreateMergePatch(from, to Hash) Hash {
+	return HashFromMap(createMergePatch(from.data, to.data))
+}
+
+func createMergePatch(
+	from map[string]interface{}, to map[string]interface{},
+) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, val := range from {
+		if val != nil && to[key] == nil {
+			patch[key] = nil
+		}
+	}
+
+	for key, val := range to {
+		if val == nil {
+			continue
+		}
+
+		fromMap, fromIsMap := toStringMap(from[key])
+		toMap, toIsMap := toStringMap(val)
+		if fromIsMap && toIsMap {
+			if sub := createMergePatch(fromMap, toMap); len(sub) > 0 {
+				patch[key] = sub
+			}
+			continue
+		}
+
+		if from[key] == nil || !valuesEqual(from[key], val) {
+			patch[key] = copyValue(val)
+		}
+	}
+
+	return patch
+}
@@ -0,0 +1,104 @@
+package zhash
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7396 appendix A
+	tests := []struct {
+		target, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+	}
+
+	for i, test := range tests {
+		target, patch, result := NewHash(), NewHash(), NewHash()
+		for _, h := range []struct {
+			hash *Hash
+			src  string
+		}{{&target, test.target}, {&patch, test.patch}, {&result, test.result}} {
+			if err := json.Unmarshal([]byte(h.src), &h.hash.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		target.MergePatch(patch)
+		if !reflect.DeepEqual(target.GetRoot(), result.GetRoot()) {
+			t.Errorf("#%d: MergePatch(%s, %s)=%s; want %s",
+				i, test.target, test.patch, target, test.result)
+		}
+	}
+}
+
+func TestMergePatchCopies(t *testing.T) {
+	hash := NewHash()
+	patch := HashFromMap(map[string]interface{}{
+		"list": []interface{}{1},
+		"map":  map[interface{}]interface{}{"key": "value"},
+	})
+
+	hash.MergePatch(patch)
+	patch.Set(2, "list", "0")
+
+	expected := map[string]interface{}{
+		"list": []interface{}{1},
+		"map":  map[string]interface{}{"key": "value"},
+	}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("MergePatch()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	from := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+			"user": "admin",
+		},
+		"tags":    []interface{}{"a"},
+		"removed": 1,
+		"same":    map[string]interface{}{"key": 1},
+	})
+	to := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432.0,
+		},
+		"tags":  []interface{}{"a", "b"},
+		"added": "value",
+		"same":  map[string]interface{}{"key": 1},
+	})
+
+	patch := CreateMergePatch(from, to)
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"user": nil,
+		},
+		"tags":    []interface{}{"a", "b"},
+		"removed": nil,
+		"added":   "value",
+	}
+	if !reflect.DeepEqual(patch.GetRoot(), expected) {
+		t.Errorf("CreateMergePatch()=%#v; want %#v", patch.GetRoot(), expected)
+	}
+
+	from.MergePatch(patch)
+	if len(CreateMergePatch(from, to).GetRoot()) != 0 {
+		t.Errorf("CreateMergePatch after MergePatch=%s", CreateMergePatch(from, to))
+	}
+}
@@ -0,0 +1,113 @@
+package zhash
+
+import "reflect"
+
+// Enables normalization of hash after every ReadHash (and so ReadFile), see
+// Normalize.
+func (h *Hash) SetNormalize(enabled bool) {
+	h.normalize = enabled
+}
+
+// Converts all yaml maps (map[interface{}]interface{}) found in hash,
+// including maps inside slices, to map[string]interface{} in place.
+// Non string keys are formatted by fmt.Sprint.
+//
+// Without normalization Get and friends never change the hash, so yaml maps
+// are returned as converted copies and changes of maps returned by GetMap or
+// GetHash are lost. Set, Delete and Append* convert yaml maps on their path
+// in place, as they change the hash anyway.
+func (h Hash) Normalize() {
+	for key, val := range h.data {
+		if normalized, ok := normalizeValue(val); ok {
+			h.data[key] = normalized
+		}
+	}
+}
+
+// normalizeValue converts yaml maps found in value in place. If value itself
+// is a yaml map, converted map is returned with true, and it must replace
+// the value.
+func normalizeValue(value interface{}) (interface{}, bool) {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := convertToMapString(typed)
+		normalizeMap(converted)
+		return converted, true
+
+	case map[string]interface{}:
+		normalizeMap(typed)
+
+	case []interface{}:
+		for i, elem := range typed {
+			if normalized, ok := normalizeValue(elem); ok {
+				typed[i] = normalized
+			}
+		}
+	}
+
+	return value, false
+}
+
+func normalizeMap(m map[string]interface{}) {
+	for key, val := range m {
+		if normalized, ok := normalizeValue(val); ok {
+			m[key] = normalized
+		}
+	}
+}
+
+// normalizedLookup walks from node by path like lookup does, but yaml maps
+// found on the way are converted and replace the original ones in their
+// parents. Yaml maps inside found []interface{} are converted too. It is
+// meant for mutating operations only, readers must use lookup.
+func normalizedLookup(node interface{}, path []string) interface{} {
+	for _, p := range path {
+		next, ok := child(node, p)
+		if !ok {
+			return nil
+		}
+
+		if typed, ok := next.(map[interface{}]interface{}); ok {
+			converted := convertToMapString(typed)
+			setChild(node, p, converted)
+			next = converted
+		}
+		node = next
+	}
+
+	if slice, ok := node.([]interface{}); ok {
+		for i, elem := range slice {
+			if typed, ok := elem.(map[interface{}]interface{}); ok {
+				slice[i] = convertToMapString(typed)
+			}
+		}
+	}
+
+	return node
+}
+
+// setChild replaces value under key of map or slice node.
+func setChild(node interface{}, key string, value interface{}) {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		typed[key] = value
+		return
+	case map[interface{}]interface{}:
+		typed[key] = value
+		return
+	}
+
+	slice, ok := asSlice(node)
+	if !ok {
+		return
+	}
+	index, ok := sliceIndex(key)
+	if !ok || index >= slice.Len() {
+		return
+	}
+
+	elem := reflect.ValueOf(value)
+	if elem.Type().AssignableTo(slice.Type().Elem()) {
+		slice.Index(index).Set(elem)
+	}
+}
@@ -0,0 +1,219 @@
+package zhash
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func yamlBase() Hash {
+	return HashFromMap(map[string]interface{}{
+		"db": map[interface{}]interface{}{
+			"host": "localhost",
+			"pool": map[interface{}]interface{}{"size": 10},
+		},
+		"ports": map[interface{}]interface{}{
+			80:    "http",
+			443:   "https",
+			true:  "yes",
+			"443": "string wins",
+		},
+		"servers": []interface{}{
+			map[interface{}]interface{}{"name": "a"},
+			map[interface{}]interface{}{"name": "b"},
+		},
+	})
+}
+
+func TestConvertNonStringKeys(t *testing.T) {
+	h := yamlBase()
+
+	expected := map[string]interface{}{
+		"80":   "http",
+		"443":  "string wins",
+		"true": "yes",
+	}
+	if ports := h.Get("ports"); !reflect.DeepEqual(ports, expected) {
+		t.Errorf("Get(ports)=%#v; want %#v", ports, expected)
+	}
+	if port, _ := h.GetString("ports", "80"); port != "http" {
+		t.Errorf("GetString(ports, 80)=%q; want http", port)
+	}
+}
+
+func TestYamlGetReadOnly(t *testing.T) {
+	h := yamlBase()
+
+	db, err := h.GetMap("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db["user"] = "admin"
+	h.GetMapSlice("servers")
+
+	if _, ok := h.GetRoot()["db"].(map[interface{}]interface{}); !ok {
+		t.Errorf("GetMap(db) converted yaml map in place")
+	}
+	if _, ok := h.GetRoot()["servers"].([]interface{})[0].(map[interface{}]interface{}); !ok {
+		t.Errorf("GetMapSlice(servers) converted yaml map in place")
+	}
+	if user := h.Get("db", "user"); user != nil {
+		t.Errorf("Get(db, user)=%#v; want nil", user)
+	}
+}
+
+func TestYamlWriteOnChange(t *testing.T) {
+	h := yamlBase()
+
+	h.Set(20, "db", "pool", "size")
+	if err := h.Delete("ports", "80"); err != nil {
+		t.Errorf("Delete from yaml map: %v", err)
+	}
+	if err := h.AppendMapSlice(map[string]interface{}{"name": "c"}, "servers"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []getTest{
+		{[]string{"db", "host"}, "localhost", false},
+		{[]string{"db", "pool", "size"}, 20, false},
+		{[]string{"ports", "80"}, nil, false},
+		{[]string{"ports", "443"}, "string wins", false},
+		{[]string{"servers", "2", "name"}, "c", false},
+	}
+	for i, test := range tests {
+		if value := h.Get(test.path...); value != test.value {
+			t.Errorf("#%d: Get(%v)=%#v; want %#v", i, test.path, value, test.value)
+		}
+	}
+}
+
+func TestYamlWriteThrough(t *testing.T) {
+	h := yamlBase()
+	h.Normalize()
+
+	db, err := h.GetMap("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db["user"] = "admin"
+
+	pool, err := h.GetHash("db", "pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Set(20, "size")
+
+	servers, err := h.GetMapSlice("servers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers[1]["port"] = 8080
+
+	tests := []getTest{
+		{[]string{"db", "user"}, "admin", false},
+		{[]string{"db", "pool", "size"}, 20, false},
+		{[]string{"servers", "1", "port"}, 8080, false},
+	}
+	for i, test := range tests {
+		if value := h.Get(test.path...); value != test.value {
+			t.Errorf("#%d: Get(%v)=%#v; want %#v", i, test.path, value, test.value)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	h := yamlBase()
+	h.Normalize()
+
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"pool": map[string]interface{}{"size": 10},
+		},
+		"ports": map[string]interface{}{
+			"80":   "http",
+			"443":  "string wins",
+			"true": "yes",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b"},
+		},
+	}
+	if !reflect.DeepEqual(h.GetRoot(), expected) {
+		t.Errorf("Normalize()=%#v; want %#v", h.GetRoot(), expected)
+	}
+}
+
+func TestReadHashNormalize(t *testing.T) {
+	h := NewHash()
+	h.SetUnmarshallerFunc(yaml.Unmarshal)
+	h.SetNormalize(true)
+
+	err := h.ReadHash(bytes.NewBufferString("a:\n  1: x\n  list:\n  - b: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"a": map[string]interface{}{
+			"1":    "x",
+			"list": []interface{}{map[string]interface{}{"b": 2}},
+		},
+	}
+	if !reflect.DeepEqual(h.GetRoot(), expected) {
+		t.Errorf("ReadHash()=%#v; want %#v", h.GetRoot(), expected)
+	}
+}
+
+func TestYamlHashConcurrentReads(t *testing.T) {
+	h := yamlBase()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.GetInt("db", "pool", "size")
+				h.GetString("ports", "80")
+				h.GetMapSlice("servers")
+				h.GetHash("db")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestYamlConcurrentReads(t *testing.T) {
+	s := NewSyncHash(yamlBase())
+	snapshot := yamlBase().Snapshot()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Get("db", "pool", "size")
+				s.GetMapSlice("servers")
+				snapshot.Get("db", "pool", "size")
+				snapshot.GetMapSlice("servers")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			s.Do(func(h *Hash) error {
+				h.Set(map[interface{}]interface{}{1: j}, "db", "pool")
+				return nil
+			})
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,245 @@
+package zhash
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// listeners keeps change subscriptions of a hash. It is shared by all copies
+// of Hash value, as they share the data.
+type listeners struct {
+	mu      sync.Mutex
+	nextID  int
+	entries map[int]listener
+	// depth counts nested changes, only the outermost one notifies
+	depth int
+}
+
+type listener struct {
+	pattern []string
+	fn      func(path []string, old, new interface{})
+}
+
+func newListeners() *listeners {
+	return &listeners{entries: map[int]listener{}}
+}
+
+// Subscribes fn to changes of value under path pattern. Pattern is written
+// in ParsePath syntax, and "*" element matches any single key or index, like
+// "servers.*.port". Fn is called with old and new value after any change
+// touching the path, its parents or descendants: Set and friends, Delete,
+// Append<Type>Slice, Merge, MergePatch, ApplyPatch, ReadHash and SetRoot.
+// Missing value is passed as nil. Values are copies, so fn can keep them.
+//
+// Subscriptions are shared by all copies of h, but not by hashes returned
+// from GetHash. Fn is called synchronously by the goroutine which changed
+// the hash, so for SyncHash it is called under write lock. Returned function
+// cancels the subscription.
+//
+//	cancel, err := h.OnChange("feature_flags", func(old, new interface{}) {
+//		log.Printf("feature flags changed to %v", new)
+//	})
+func (h *Hash) OnChange(
+	pattern string, fn func(old, new interface{}),
+) (func(), error) {
+	return h.OnChangePath(pattern, func(_ []string, old, new interface{}) {
+		fn(old, new)
+	})
+}
+
+// Subscribes fn to changes like OnChange does, but fn gets path of changed
+// value too, which is handy for patterns with wildcards.
+func (h *Hash) OnChangePath(
+	pattern string, fn func(path []string, old, new interface{}),
+) (func(), error) {
+	p, err := ParsePath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.listeners == nil {
+		h.listeners = newListeners()
+	}
+
+	l := h.listeners
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.nextID
+	l.nextID++
+	l.entries[id] = listener{p, fn}
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.entries, id)
+	}, nil
+}
+
+type changeEvent struct {
+	listener listener
+	path     []string
+	old, new interface{}
+}
+
+// notifyChange calls change, which touches values under prefix (nil prefix
+// means whole hash), and notifies listeners whose values have changed.
+func (h *Hash) notifyChange(prefix []string, change func() error) error {
+	l := h.listeners
+	if l == nil {
+		return change()
+	}
+
+	l.mu.Lock()
+	if len(l.entries) == 0 || l.depth > 0 {
+		l.mu.Unlock()
+		return change()
+	}
+
+	active := make([]listener, 0, len(l.entries))
+	ids := make([]int, 0, len(l.entries))
+	for id := range l.entries {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		active = append(active, l.entries[id])
+	}
+	l.depth++
+	l.mu.Unlock()
+
+	type watched struct {
+		path    []string
+		old     interface{}
+		present bool
+	}
+
+	targets := make([]map[string]*watched, len(active))
+	for i, listener := range active {
+		targets[i] = map[string]*watched{}
+		for _, path := range watchedPaths(h.data, listener.pattern, prefix) {
+			old, present := lookup(h.data, path)
+			targets[i][FormatPath(path)] = &watched{path, copyValue(old), present}
+		}
+	}
+
+	err := change()
+
+	var events []changeEvent
+	for i, listener := range active {
+		for _, path := range watchedPaths(h.data, listener.pattern, prefix) {
+			key := FormatPath(path)
+			if _, ok := targets[i][key]; !ok {
+				targets[i][key] = &watched{path: path}
+			}
+		}
+
+		keys := make([]string, 0, len(targets[i]))
+		for key := range targets[i] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			target := targets[i][key]
+			new, present := lookup(h.data, target.path)
+			if present == target.present &&
+				(!present || valuesEqual(target.old, new)) {
+				continue
+			}
+			events = append(events, changeEvent{
+				listener, target.path, target.old, copyValue(new),
+			})
+		}
+	}
+
+	l.mu.Lock()
+	l.depth--
+	l.mu.Unlock()
+
+	for _, event := range events {
+		event.listener.fn(event.path, event.old, event.new)
+	}
+
+	return err
+}
+
+// changePrefix returns prefix of values affected by change of value under
+// path. Changes of slice elements may shift or add siblings, so the whole
+// slice is affected.
+func (h Hash) changePrefix(path []string) []string {
+	if len(path) == 0 || h.listeners == nil {
+		return path
+	}
+
+	parent, _ := lookup(h.data, path[:len(path)-1])
+	if _, ok := asSlice(parent); ok {
+		return path[:len(path)-1]
+	}
+	return path
+}
+
+// watchedPaths returns concrete paths matching pattern which may be affected
+// by change under prefix. Wildcards are expanded against node.
+func watchedPaths(node interface{}, pattern []string, prefix []string) [][]string {
+	common := len(pattern)
+	if len(prefix) < common {
+		common = len(prefix)
+	}
+
+	for i := 0; i < common; i++ {
+		if pattern[i] != "*" && pattern[i] != prefix[i] {
+			return nil
+		}
+	}
+
+	if len(pattern) <= len(prefix) {
+		return [][]string{append([]string{}, prefix[:len(pattern)]...)}
+	}
+
+	base := append([]string{}, prefix...)
+	node, _ = lookup(node, base)
+
+	var result [][]string
+	expandPattern(node, pattern[len(prefix):], base, &result)
+	return result
+}
+
+func expandPattern(
+	node interface{}, pattern []string, path []string, result *[][]string,
+) {
+	if len(pattern) == 0 {
+		*result = append(*result, append([]string{}, path...))
+		return
+	}
+
+	if pattern[0] != "*" {
+		next, _ := child(node, pattern[0])
+		expandPattern(next, pattern[1:], append(path, pattern[0]), result)
+		return
+	}
+
+	for _, key := range childKeys(node) {
+		next, _ := child(node, key)
+		expandPattern(next, pattern[1:], append(path, key), result)
+	}
+}
+
+// childKeys returns keys of map or indexes of slice.
+func childKeys(node interface{}) []string {
+	if m, ok := toStringMap(node); ok {
+		return sortedKeys(m)
+	}
+
+	slice, ok := asSlice(node)
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, slice.Len())
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	return keys
+}
@@ -0,0 +1,164 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type changeRecord struct {
+	path     string
+	old, new interface{}
+}
+
+func recordChanges(t *testing.T, h *Hash, pattern string) *[]changeRecord {
+	var records []changeRecord
+	_, err := h.OnChangePath(pattern, func(path []string, old, new interface{}) {
+		records = append(records, changeRecord{FormatPath(path), old, new})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &records
+}
+
+func checkChanges(t *testing.T, step string, records *[]changeRecord, expected ...changeRecord) {
+	if len(*records) == 0 && len(expected) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(*records, expected) {
+		t.Errorf("%s: changes=%#v; want %#v", step, *records, expected)
+	}
+	*records = nil
+}
+
+func TestOnChange(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"feature_flags": map[string]interface{}{"beta": false},
+		"other":         1,
+	})
+	flags := recordChanges(t, &h, "feature_flags")
+	beta := recordChanges(t, &h, "feature_flags.beta")
+
+	h.Set(2, "other")
+	checkChanges(t, "Set(other)", flags)
+
+	h.Set(true, "feature_flags", "beta")
+	checkChanges(t, "Set(beta)", flags, changeRecord{
+		"feature_flags",
+		map[string]interface{}{"beta": false},
+		map[string]interface{}{"beta": true},
+	})
+	checkChanges(t, "Set(beta)", beta, changeRecord{"feature_flags.beta", false, true})
+
+	h.Set(true, "feature_flags", "beta")
+	checkChanges(t, "Set(beta) again", flags)
+
+	h.Set("off", "feature_flags")
+	checkChanges(t, "Set(feature_flags)", beta,
+		changeRecord{"feature_flags.beta", true, nil})
+	checkChanges(t, "Set(feature_flags)", flags, changeRecord{
+		"feature_flags", map[string]interface{}{"beta": true}, "off",
+	})
+
+	h.Delete("feature_flags")
+	checkChanges(t, "Delete", flags, changeRecord{"feature_flags", "off", nil})
+
+	h.AppendStringSlice("a", "feature_flags")
+	checkChanges(t, "AppendStringSlice", flags,
+		changeRecord{"feature_flags", nil, []string{"a"}})
+
+	h.Delete("feature_flags", "0")
+	checkChanges(t, "Delete from slice", flags,
+		changeRecord{"feature_flags", []string{"a"}, []string{}})
+
+	h.Merge(HashFromMap(map[string]interface{}{
+		"feature_flags": map[string]interface{}{"gamma": true},
+	}))
+	checkChanges(t, "Merge", flags, changeRecord{
+		"feature_flags", []string{}, map[string]interface{}{"gamma": true},
+	})
+
+	h.MergePatch(HashFromMap(map[string]interface{}{"feature_flags": nil}))
+	checkChanges(t, "MergePatch", flags, changeRecord{
+		"feature_flags", map[string]interface{}{"gamma": true}, nil,
+	})
+
+	h.ApplyPatch([]PatchOp{{Op: "add", Path: "/feature_flags", Value: 1}})
+	checkChanges(t, "ApplyPatch", flags, changeRecord{"feature_flags", nil, 1})
+
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	h.SetRoot(map[string]interface{}{})
+	checkChanges(t, "SetRoot", flags, changeRecord{"feature_flags", 1, nil})
+
+	h.ReadHash(bytes.NewBufferString(`{"feature_flags": "on"}`))
+	checkChanges(t, "ReadHash", flags, changeRecord{"feature_flags", nil, "on"})
+}
+
+func TestOnChangeWildcard(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 81},
+		},
+	})
+	ports := recordChanges(t, &h, "servers.*.port")
+
+	h.Set(8080, "servers", "1", "port")
+	checkChanges(t, "Set(port)", ports, changeRecord{"servers.1.port", 81, 8080})
+
+	h.Delete("servers", "0")
+	checkChanges(t, "Delete(server)", ports,
+		changeRecord{"servers.0.port", 80, 8080},
+		changeRecord{"servers.1.port", 8080, nil},
+	)
+
+	h.Set("x", "servers", "0", "name")
+	checkChanges(t, "Set(name)", ports)
+
+	h.Set([]interface{}{map[string]interface{}{"port": 1}}, "servers")
+	checkChanges(t, "Set(servers)", ports,
+		changeRecord{"servers.0.port", 8080, 1})
+}
+
+func TestOnChangeCancel(t *testing.T) {
+	h := NewHash()
+	calls := 0
+	cancel, err := h.OnChange("a", func(old, new interface{}) {
+		calls++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// copies share subscriptions
+	copied := h
+	copied.Set(1, "a")
+	cancel()
+	h.Set(2, "a")
+
+	if calls != 1 {
+		t.Errorf("listener called %d times; want 1", calls)
+	}
+
+	if _, err := h.OnChange("a..b", func(old, new interface{}) {}); err == nil {
+		t.Error("OnChange with malformed pattern does not fail")
+	}
+}
+
+func TestOnChangeNested(t *testing.T) {
+	h := NewHash()
+	var values []interface{}
+	h.OnChange("counter", func(old, new interface{}) {
+		values = append(values, new)
+		if n, _ := new.(int); n < 3 {
+			h.Set(n+1, "counter")
+		}
+	})
+
+	h.Set(1, "counter")
+	if expected := []interface{}{1, 2, 3}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("values=%v; want %v", values, expected)
+	}
+}
@@ -0,0 +1,247 @@
+package zhash
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SetSyntaxError is returned when override in Helm --set syntax can not be
+// parsed. Pos is the byte offset in Input where error is found.
+type SetSyntaxError struct {
+	Input string
+	Pos   int
+	Msg   string
+}
+
+func (e *SetSyntaxError) Error() string {
+	return fmt.Sprintf("cannot parse %q at %d: %s", e.Input, e.Pos, e.Msg)
+}
+
+type setMode int
+
+const (
+	setTyped setMode = iota
+	setString
+	setFile
+)
+
+type override struct {
+	path  []segment
+	value interface{}
+	// position of the key in input
+	pos int
+}
+
+// Parses overrides in Helm --set syntax into new Hash, see ApplySet.
+func ParseSet(s string) (Hash, error) {
+	h := NewHash()
+	err := h.ApplySet(s)
+	return h, err
+}
+
+// Applies overrides in Helm --set syntax: comma separated list of
+// path=value pairs, like "a.b[0].c=10,x=y". Paths use ParsePath syntax,
+// missing parents written as indexes are created as slices. Values looking
+// like ints, floats or bools are set as int, float64 or bool, "null" deletes
+// the key, and {a,b} sets a list. Backslash escapes commas, dots, brackets,
+// braces and equal signs. Nothing is changed if s can not be parsed.
+//
+// Slices are not grown beyond index 65536, such override fails with
+// *SetSyntaxError pointing to its key, and overrides before it stay
+// applied.
+func (h Hash) ApplySet(s string) error {
+	return h.applySet(s, setTyped)
+}
+
+// Applies overrides like ApplySet does, but keeps all values as strings
+// (Helm --set-string).
+func (h Hash) ApplySetString(s string) error {
+	return h.applySet(s, setString)
+}
+
+// Applies overrides like ApplySet does, but each value is a name of file
+// whose content is set as a string (Helm --set-file).
+func (h Hash) ApplySetFile(s string) error {
+	return h.applySet(s, setFile)
+}
+
+func (h Hash) applySet(s string, mode setMode) error {
+	overrides, err := parseOverrides(s, mode)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range overrides {
+		if o.value == nil {
+			err := h.Delete(segmentKeys(o.path)...)
+			if err != nil && !IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+
+		if err := h.setSegments(o.value, o.path, false); err != nil {
+			return &SetSyntaxError{Input: s, Pos: o.pos, Msg: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+func parseOverrides(s string, mode setMode) ([]override, error) {
+	var (
+		overrides []override
+		pos       int
+	)
+
+	syntaxError := func(pos int, msg string) error {
+		return &SetSyntaxError{Input: s, Pos: pos, Msg: msg}
+	}
+
+	for {
+		keyStart := pos
+		raw, end, stop := scanUntil(s, pos, "=,")
+		if stop != "" {
+			return nil, syntaxError(end, stop)
+		}
+		if end == len(s) || s[end] != '=' {
+			return nil, syntaxError(end, "expected '='")
+		}
+		if raw == "" {
+			return nil, syntaxError(keyStart, "empty key")
+		}
+
+		path, err := parseSegments(s[keyStart:end])
+		if err != nil {
+			var pathErr pathSyntaxError
+			if errors.As(err, &pathErr) {
+				return nil, syntaxError(keyStart+pathErr.pos, pathErr.msg)
+			}
+			return nil, err
+		}
+
+		pos = end + 1
+
+		var value interface{}
+		if mode != setFile && pos < len(s) && s[pos] == '{' {
+			list := []interface{}{}
+			pos++
+			for {
+				elem, end, stop := scanUntil(s, pos, ",}")
+				if stop != "" {
+					return nil, syntaxError(end, stop)
+				}
+				if end == len(s) {
+					return nil, syntaxError(end, "unterminated list")
+				}
+				if elem != "" || s[end] == ',' || len(list) > 0 {
+					list = append(list, typedValue(elem, mode))
+				}
+				pos = end + 1
+				if s[end] == '}' {
+					break
+				}
+			}
+			value = list
+		} else {
+			raw, end, stop := scanUntil(s, pos, ",")
+			if stop != "" {
+				return nil, syntaxError(end, stop)
+			}
+			pos = end
+
+			if mode == setFile {
+				content, err := os.ReadFile(raw)
+				if err != nil {
+					return nil, err
+				}
+				value = string(content)
+			} else {
+				value = typedValue(raw, mode)
+			}
+		}
+
+		overrides = append(overrides, override{path, value, keyStart})
+
+		if pos == len(s) {
+			return overrides, nil
+		}
+		if s[pos] != ',' {
+			return nil, syntaxError(pos, "expected ','")
+		}
+		pos++
+	}
+}
+
+// scanUntil reads s from pos until one of unescaped stop bytes or end of s.
+// Returns unescaped text and position of stop byte. Trailing backslash is
+// reported via non empty stop message.
+func scanUntil(s string, pos int, stops string) (string, int, string) {
+	var buf strings.Builder
+	for i := pos; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' {
+			if i == len(s)-1 {
+				return "", i, "trailing backslash"
+			}
+			i++
+			buf.WriteByte(s[i])
+			continue
+		}
+
+		if strings.IndexByte(stops, c) >= 0 {
+			return buf.String(), i, ""
+		}
+		buf.WriteByte(c)
+	}
+
+	return buf.String(), len(s), ""
+}
+
+func typedValue(s string, mode setMode) interface{} {
+	if mode == setString {
+		return s
+	}
+	if s == "null" {
+		return nil
+	}
+	return inferValue(s, "")
+}
+
+type setFlag struct {
+	hash   Hash
+	mode   setMode
+	values []string
+}
+
+func (f *setFlag) String() string {
+	return strings.Join(f.values, ",")
+}
+
+func (f *setFlag) Set(s string) error {
+	if err := f.hash.applySet(s, f.mode); err != nil {
+		return err
+	}
+	f.values = append(f.values, s)
+	return nil
+}
+
+// Returns flag.Value applying ApplySet to h for each flag occurrence.
+//
+//	flag.Var(zhash.SetFlag(h), "set", "set values (a.b=1,c=2)")
+func SetFlag(h Hash) flag.Value {
+	return &setFlag{hash: h, mode: setTyped}
+}
+
+// Returns flag.Value applying ApplySetString to h for each flag occurrence.
+func SetStringFlag(h Hash) flag.Value {
+	return &setFlag{hash: h, mode: setString}
+}
+
+// Returns flag.Value applying ApplySetFile to h for each flag occurrence.
+func SetFileFlag(h Hash) flag.Value {
+	return &setFlag{hash: h, mode: setFile}
+}
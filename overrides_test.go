@@ -0,0 +1,163 @@
+package zhash
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplySet(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"remove": "me",
+		"keep":   "me",
+	})
+
+	err := hash.ApplySet(
+		`a.b[1].c=10,x=y,flag=true,ratio=0.5,zip=007,list={a,2,false},` +
+			`empty={},escaped=a\,b,dotted\.key=1,remove=null,eq\=key=v\=1`,
+	)
+	if err != nil {
+		t.Fatalf("ApplySet caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{nil, map[string]interface{}{"c": 10}},
+		},
+		"x":          "y",
+		"flag":       true,
+		"ratio":      0.5,
+		"zip":        "007",
+		"list":       []interface{}{"a", 2, false},
+		"empty":      []interface{}{},
+		"escaped":    "a,b",
+		"dotted.key": 1,
+		"keep":       "me",
+		"eq=key":     "v=1",
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("ApplySet()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestApplySetString(t *testing.T) {
+	hash, err := ParseSet("port=8080")
+	if err != nil {
+		t.Fatalf("ParseSet caused error: %v", err)
+	}
+
+	err = hash.ApplySetString("zip=007,port=8081,list={1,true}")
+	if err != nil {
+		t.Fatalf("ApplySetString caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"zip":  "007",
+		"port": "8081",
+		"list": []interface{}{"1", "true"},
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("ApplySetString()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestApplySetFile(t *testing.T) {
+	cert := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(cert, []byte("CERT\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := NewHash()
+	if err := hash.ApplySetFile("tls.ca=" + cert); err != nil {
+		t.Fatalf("ApplySetFile caused error: %v", err)
+	}
+	if v, _ := hash.GetString("tls", "ca"); v != "CERT\n" {
+		t.Errorf("ApplySetFile()=%q", v)
+	}
+
+	if err := hash.ApplySetFile("tls.ca=" + cert + ".missing"); err == nil {
+		t.Errorf("ApplySetFile with missing file doesn't cause error")
+	}
+}
+
+func TestApplySetErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"a=1,b", 5},
+		{"a=1,,b=2", 4},
+		{"=1", 0},
+		{"a..b=1", 2},
+		{"x=1,a[z]=1", 6},
+		{"a={1,2", 6},
+		{"a={1}b", 5},
+		{`a=1\`, 3},
+		{"a[20000000]=1", 0},
+	}
+
+	for i, test := range tests {
+		hash := NewHash()
+		err := hash.ApplySet(test.input)
+		syntaxErr, ok := err.(*SetSyntaxError)
+		if !ok {
+			t.Errorf("#%d: ApplySet(%q) returned %v; want *SetSyntaxError",
+				i, test.input, err)
+			continue
+		}
+		if syntaxErr.Pos != test.pos {
+			t.Errorf("#%d: ApplySet(%q) error at %d; want %d (%v)",
+				i, test.input, syntaxErr.Pos, test.pos, err)
+		}
+		if hash.Len() != 0 {
+			t.Errorf("#%d: failed ApplySet(%q) changed hash", i, test.input)
+		}
+	}
+}
+
+func TestApplySetIndexLimit(t *testing.T) {
+	hash := NewHash()
+	err := hash.ApplySet("a[0]=1,a.3000000=x")
+	syntaxErr, ok := err.(*SetSyntaxError)
+	if !ok || syntaxErr.Pos != 7 {
+		t.Fatalf("ApplySet beyond index limit returned %v; want *SetSyntaxError at 7", err)
+	}
+
+	if a, _ := hash.GetSlice("a"); len(a) != 1 {
+		t.Errorf("len(a)=%d; want 1", len(a))
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	hash := NewHash()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(SetFlag(hash), "set", "")
+	flags.Var(SetStringFlag(hash), "set-string", "")
+
+	err := flags.Parse([]string{
+		"--set", "a=1", "--set-string", "a=2,b=3", "--set", "b=4",
+	})
+	if err != nil {
+		t.Fatalf("Parse caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{"a": "2", "b": 4}
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("flags=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	if s := flags.Lookup("set-string").Value.String(); s != "a=2,b=3" {
+		t.Errorf("String()=%q", s)
+	}
+
+	if err := flags.Parse([]string{"--set", "a"}); err == nil {
+		t.Errorf("Parse of malformed --set doesn't cause error")
+	}
+}
@@ -0,0 +1,375 @@
+package zhash
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation. Path and From are JSON
+// Pointers (RFC 6901), like "/servers/0/port".
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps null value of add, replace and test operations, which
+// would be dropped by omitempty otherwise.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	type plain PatchOp
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			plain
+			Value interface{} `json:"value"`
+		}{plain(op), op.Value})
+	}
+	return json.Marshal(plain(op))
+}
+
+// Parses JSON Pointer into path suitable for Hash accessors.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf(
+			"invalid JSON pointer %q, expected leading '/'", pointer,
+		)
+	}
+
+	path := strings.Split(pointer[1:], "/")
+	for i, p := range path {
+		path[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(p)
+	}
+	return path, nil
+}
+
+// Formats path as JSON Pointer.
+func FormatPointer(path []string) string {
+	var buf strings.Builder
+	for _, p := range path {
+		buf.WriteByte('/')
+		buf.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(p))
+	}
+	return buf.String()
+}
+
+// Applies RFC 6902 JSON Patch operations to h. Operations are applied to a
+// copy, so if any of them fails h is left untouched.
+func (h Hash) ApplyPatch(ops []PatchOp) error {
+	work := &patcher{root: copyValue(h.data)}
+	for i, op := range ops {
+		if err := work.apply(op); err != nil {
+			return fmt.Errorf("patch operation #%d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	root, ok := work.root.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("patched document is %T, expected map", work.root)
+	}
+
+	h.replaceRoot(root)
+	return nil
+}
+
+type patcher struct {
+	root interface{}
+}
+
+func (p *patcher) apply(op PatchOp) error {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "add":
+		return p.add(path, copyValue(op.Value))
+
+	case "remove":
+		_, err := p.remove(path)
+		return err
+
+	case "replace":
+		if len(path) == 0 {
+			p.root = copyValue(op.Value)
+			return nil
+		}
+		if _, err := p.remove(path); err != nil {
+			return err
+		}
+		return p.add(path, copyValue(op.Value))
+
+	case "move":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		if len(path) > len(from) && hasPrefix(path, from) {
+			return fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		value, err := p.remove(from)
+		if err != nil {
+			return err
+		}
+		return p.add(path, value)
+
+	case "copy":
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		value, ok := lookup(p.root, from)
+		if !ok {
+			return notFoundError{from}
+		}
+		return p.add(path, copyValue(value))
+
+	case "test":
+		value, ok := lookup(p.root, path)
+		if !ok {
+			return notFoundError{path}
+		}
+		if !valuesEqual(value, op.Value) {
+			return fmt.Errorf("test failed, value is %v", value)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+func (p *patcher) add(path []string, value interface{}) error {
+	if len(path) == 0 {
+		p.root = value
+		return nil
+	}
+
+	parentPath, key := path[:len(path)-1], path[len(path)-1]
+	parent, ok := lookup(p.root, parentPath)
+	if !ok {
+		return notFoundError{parentPath}
+	}
+
+	if m, ok := toStringMap(parent); ok {
+		m[key] = value
+		return p.store(parentPath, m)
+	}
+
+	slice, ok := asSlice(parent)
+	if !ok {
+		return fmt.Errorf("cannot add to %T", parent)
+	}
+
+	elems := toInterfaceSlice(slice)
+	index := len(elems)
+	if key != "-" {
+		var ok bool
+		index, ok = sliceIndex(key)
+		if !ok || index > len(elems) {
+			return fmt.Errorf("invalid index %q", key)
+		}
+	}
+
+	elems = append(elems, nil)
+	copy(elems[index+1:], elems[index:])
+	elems[index] = value
+	return p.store(parentPath, elems)
+}
+
+func (p *patcher) remove(path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove root")
+	}
+
+	parentPath, key := path[:len(path)-1], path[len(path)-1]
+	parent, ok := lookup(p.root, parentPath)
+	if !ok {
+		return nil, notFoundError{path}
+	}
+
+	if m, ok := toStringMap(parent); ok {
+		value, ok := m[key]
+		if !ok {
+			return nil, notFoundError{path}
+		}
+		delete(m, key)
+		return value, p.store(parentPath, m)
+	}
+
+	slice, ok := asSlice(parent)
+	if !ok {
+		return nil, fmt.Errorf("cannot remove from %T", parent)
+	}
+
+	index, ok := sliceIndex(key)
+	if !ok || index >= slice.Len() {
+		return nil, notFoundError{path}
+	}
+
+	elems := toInterfaceSlice(slice)
+	value := elems[index]
+	elems = append(elems[:index], elems[index+1:]...)
+	return value, p.store(parentPath, elems)
+}
+
+// store puts container changed by add or remove back to its parent.
+func (p *patcher) store(path []string, value interface{}) error {
+	if len(path) == 0 {
+		p.root = value
+		return nil
+	}
+
+	root, ok := p.root.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("document root is %T, expected map", p.root)
+	}
+
+	HashFromMap(root).Set(value, path...)
+	return nil
+}
+
+// Creates RFC 6902 JSON Patch which turns a into b. Maps are compared
+// recursively, slices are compared element by element.
+func CreatePatch(a, b Hash) []PatchOp {
+	ops := []PatchOp{}
+	diffPatch(&ops, []string{}, a.data, b.data)
+	return ops
+}
+
+func diffPatch(ops *[]PatchOp, path []string, a, b interface{}) {
+	aMap, aIsMap := toStringMap(a)
+	bMap, bIsMap := toStringMap(b)
+	if aIsMap && bIsMap {
+		for _, key := range sortedKeys(aMap) {
+			if _, ok := bMap[key]; !ok {
+				*ops = append(*ops, PatchOp{
+					Op: "remove", Path: FormatPointer(append(path, key)),
+				})
+			}
+		}
+		for _, key := range sortedKeys(bMap) {
+			if _, ok := aMap[key]; !ok {
+				*ops = append(*ops, PatchOp{
+					Op:    "add",
+					Path:  FormatPointer(append(path, key)),
+					Value: copyValue(bMap[key]),
+				})
+				continue
+			}
+			diffPatch(ops, append(path, key), aMap[key], bMap[key])
+		}
+		return
+	}
+
+	aSlice, aIsSlice := asSlice(a)
+	bSlice, bIsSlice := asSlice(b)
+	if aIsSlice && bIsSlice {
+		common := aSlice.Len()
+		if bSlice.Len() < common {
+			common = bSlice.Len()
+		}
+		for i := 0; i < common; i++ {
+			diffPatch(
+				ops, append(path, strconv.Itoa(i)),
+				aSlice.Index(i).Interface(), bSlice.Index(i).Interface(),
+			)
+		}
+		for i := aSlice.Len() - 1; i >= common; i-- {
+			*ops = append(*ops, PatchOp{
+				Op: "remove", Path: FormatPointer(append(path, strconv.Itoa(i))),
+			})
+		}
+		for i := common; i < bSlice.Len(); i++ {
+			*ops = append(*ops, PatchOp{
+				Op:    "add",
+				Path:  FormatPointer(append(path, strconv.Itoa(i))),
+				Value: copyValue(bSlice.Index(i).Interface()),
+			})
+		}
+		return
+	}
+
+	if !valuesEqual(a, b) {
+		*ops = append(*ops, PatchOp{
+			Op: "replace", Path: FormatPointer(path), Value: copyValue(b),
+		})
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valuesEqual compares values as JSON does: numbers are equal if they have
+// equal value whatever their types are, maps and slices are compared
+// recursively.
+func valuesEqual(a, b interface{}) bool {
+	if aNum, ok := toNumber(a); ok {
+		bNum, ok := toNumber(b)
+		return ok && aNum == bNum
+	}
+
+	aMap, aIsMap := toStringMap(a)
+	bMap, bIsMap := toStringMap(b)
+	if aIsMap || bIsMap {
+		if !aIsMap || !bIsMap || len(aMap) != len(bMap) {
+			return false
+		}
+		for key, val := range aMap {
+			other, ok := bMap[key]
+			if !ok || !valuesEqual(val, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	aSlice, aIsSlice := asSlice(a)
+	bSlice, bIsSlice := asSlice(b)
+	if aIsSlice || bIsSlice {
+		if !aIsSlice || !bIsSlice || aSlice.Len() != bSlice.Len() {
+			return false
+		}
+		for i := 0; i < aSlice.Len(); i++ {
+			if !valuesEqual(aSlice.Index(i).Interface(), bSlice.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
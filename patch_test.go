@@ -0,0 +1,166 @@
+package zhash
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	path, err := ParsePointer("/a~1b/m~0n/0")
+	if err != nil || !reflect.DeepEqual(path, []string{"a/b", "m~n", "0"}) {
+		t.Errorf("ParsePointer()=%#v, %v", path, err)
+	}
+	if p := FormatPointer(path); p != "/a~1b/m~0n/0" {
+		t.Errorf("FormatPointer()=%q", p)
+	}
+	if _, err := ParsePointer("a/b"); err == nil {
+		t.Errorf("ParsePointer without leading slash doesn't cause error")
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"tags":    []interface{}{"a", "c"},
+		"ints":    []int{1, 2},
+		"old":     "value",
+		"servers": []interface{}{map[string]interface{}{"name": "a"}},
+	})
+
+	var ops []PatchOp
+	err := json.Unmarshal([]byte(`[
+		{"op": "test", "path": "/db/port", "value": 5432.0},
+		{"op": "replace", "path": "/db/host", "value": "db.example.com"},
+		{"op": "add", "path": "/tags/1", "value": "b"},
+		{"op": "add", "path": "/tags/-", "value": "d"},
+		{"op": "remove", "path": "/ints/0"},
+		{"op": "move", "from": "/old", "path": "/db/old"},
+		{"op": "copy", "from": "/servers/0", "path": "/servers/1"},
+		{"op": "add", "path": "/servers/1/name", "value": "b"},
+		{"op": "add", "path": "/nil", "value": null}
+	]`), &ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hash.ApplyPatch(ops); err != nil {
+		t.Fatalf("ApplyPatch caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432,
+			"old":  "value",
+		},
+		"tags": []interface{}{"a", "b", "c", "d"},
+		"ints": []interface{}{2},
+		"servers": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b"},
+		},
+		"nil": nil,
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("ApplyPatch()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	tests := [][]PatchOp{
+		{{Op: "remove", Path: "/missing"}},
+		{{Op: "replace", Path: "/list/5", Value: 1}},
+		{{Op: "add", Path: "/missing/key", Value: 1}},
+		{{Op: "add", Path: "/list/3", Value: 1}},
+		{{Op: "test", Path: "/key", Value: "other"}},
+		{{Op: "move", From: "/map", Path: "/map/sub"}},
+		{{Op: "copy", From: "/missing", Path: "/key"}},
+		{{Op: "unknown", Path: "/key"}},
+		{{Op: "add", Path: "key", Value: 1}},
+		{{Op: "replace", Path: "", Value: 1}},
+		{{Op: "remove", Path: "/key/sub"}},
+	}
+
+	for i, ops := range tests {
+		hash := HashFromMap(map[string]interface{}{
+			"key":  "value",
+			"list": []interface{}{1},
+			"map":  map[string]interface{}{"a": 1},
+		})
+
+		ops = append([]PatchOp{{Op: "add", Path: "/added", Value: 1}}, ops...)
+		if err := hash.ApplyPatch(ops); err == nil {
+			t.Errorf("#%d: ApplyPatch(%v) doesn't cause error", i, ops)
+		}
+		if hash.Get("added") != nil {
+			t.Errorf("#%d: failed ApplyPatch changed hash", i)
+		}
+	}
+}
+
+func TestCreatePatch(t *testing.T) {
+	a := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"tags":    []interface{}{"a", "b", "c"},
+		"ports":   []int{80},
+		"removed": true,
+		"same":    10,
+	})
+	b := HashFromMap(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.example.com",
+			"port": 5432.0,
+		},
+		"tags":  []interface{}{"a", "x"},
+		"ports": []interface{}{80, 443},
+		"added": map[string]interface{}{"key": nil},
+		"same":  10,
+	})
+
+	ops := CreatePatch(a, b)
+	expected := []PatchOp{
+		{Op: "remove", Path: "/removed"},
+		{Op: "add", Path: "/added", Value: map[string]interface{}{"key": nil}},
+		{Op: "replace", Path: "/db/host", Value: "db.example.com"},
+		{Op: "add", Path: "/ports/1", Value: 443},
+		{Op: "replace", Path: "/tags/1", Value: "x"},
+		{Op: "remove", Path: "/tags/2"},
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("CreatePatch()=%#v; want %#v", ops, expected)
+	}
+
+	if err := a.ApplyPatch(ops); err != nil {
+		t.Fatalf("ApplyPatch(CreatePatch()) caused error: %v", err)
+	}
+	if len(CreatePatch(a, b)) != 0 {
+		t.Errorf("CreatePatch after applying patch=%v", CreatePatch(a, b))
+	}
+}
+
+func TestPatchOpJSON(t *testing.T) {
+	ops := []PatchOp{
+		{Op: "add", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b"},
+		{Op: "move", From: "/c", Path: "/d"},
+	}
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"op":"add","path":"/a","value":null},` +
+		`{"op":"remove","path":"/b"},{"op":"move","path":"/d","from":"/c"}]`
+	if string(b) != expected {
+		t.Errorf("json.Marshal()=%s; want %s", b, expected)
+	}
+}
@@ -0,0 +1,363 @@
+package zhash
+
+import (
+	"fmt"
+	"strings"
+)
+
+type pathSyntaxError struct {
+	path string
+	pos  int
+	msg  string
+}
+
+func (e pathSyntaxError) Error() string {
+	return fmt.Sprintf("invalid path %q at %d: %s", e.path, e.pos, e.msg)
+}
+
+// segment is a single element of path. Segments written in brackets are
+// marked as index, so setting value through them creates slices instead of
+// maps for missing parents.
+type segment struct {
+	key   string
+	index bool
+}
+
+// Parses string path representation into slice of keys, suitable for passing
+// to any Hash accessor. Keys are separated by dots, slice indexes are written
+// in square brackets, and backslash escapes dots, brackets and backslash
+// itself, so "servers[2].port" becomes []string{"servers", "2", "port"} and
+// "hosts.example\.com" becomes []string{"hosts", "example.com"}. Empty string
+// is the root path.
+func ParsePath(path string) ([]string, error) {
+	segments, err := parseSegments(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return segmentKeys(segments), nil
+}
+
+func parseSegments(path string) ([]segment, error) {
+	const (
+		stateStart = iota
+		stateKey
+		stateDot
+		stateIndex
+	)
+
+	var (
+		result = []segment{}
+		key    strings.Builder
+		state  = stateStart
+	)
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '.':
+			if state != stateKey && state != stateIndex {
+				return nil, pathSyntaxError{path, i, "empty key"}
+			}
+			if state == stateKey {
+				result = append(result, segment{key: key.String()})
+				key.Reset()
+			}
+			state = stateDot
+
+		case c == '[':
+			if state == stateDot {
+				return nil, pathSyntaxError{path, i, "empty key"}
+			}
+			if state == stateKey {
+				result = append(result, segment{key: key.String()})
+				key.Reset()
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, pathSyntaxError{path, i, "unterminated index"}
+			}
+			index := path[i+1 : i+end]
+			if !isIndex(index) {
+				return nil, pathSyntaxError{
+					path, i + 1, fmt.Sprintf("invalid index %q", index),
+				}
+			}
+			result = append(result, segment{index, true})
+			i += end
+			state = stateIndex
+
+		case c == ']':
+			return nil, pathSyntaxError{path, i, "unexpected ']'"}
+
+		case state == stateIndex:
+			return nil, pathSyntaxError{path, i, "expected '.' or '[' after index"}
+
+		case c == '\\':
+			if i == len(path)-1 {
+				return nil, pathSyntaxError{path, i, "trailing backslash"}
+			}
+			i++
+			key.WriteByte(path[i])
+			state = stateKey
+
+		default:
+			key.WriteByte(c)
+			state = stateKey
+		}
+	}
+
+	switch state {
+	case stateKey:
+		result = append(result, segment{key: key.String()})
+	case stateDot:
+		return nil, pathSyntaxError{path, len(path), "empty key"}
+	}
+
+	return result, nil
+}
+
+// Path is like ParsePath, but panics if path can not be parsed. It is handy
+// for paths written as constants:
+//
+//	h.Set(8080, zhash.Path("servers[2].port")...)
+func Path(path string) []string {
+	p, err := ParsePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Formats path back to string representation accepted by ParsePath. Dots,
+// brackets and backslashes inside keys are escaped.
+func FormatPath(path []string) string {
+	var buf strings.Builder
+	for i, p := range path {
+		if i > 0 {
+			buf.WriteByte('.')
+		}
+		for j := 0; j < len(p); j++ {
+			switch p[j] {
+			case '.', '[', ']', '\\':
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(p[j])
+		}
+	}
+	return buf.String()
+}
+
+func segmentKeys(segments []segment) []string {
+	keys := make([]string, len(segments))
+	for i, s := range segments {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Retrieves value by string path, see ParsePath for path syntax. Returns nil
+// if nothing found or path is malformed.
+func (h Hash) GetP(path string) interface{} {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil
+	}
+	return h.Get(p...)
+}
+
+// Sets value by string path, see ParsePath for path syntax. Unlike Set,
+// missing parents written as indexes ("list[0]") are created as slices.
+func (h Hash) SetP(value interface{}, path string) error {
+	p, err := parseSegments(path)
+	if err != nil {
+		return err
+	}
+	if len(p) == 0 {
+		p = []segment{{}}
+	}
+	return h.setSegments(value, p, false)
+}
+
+func (h Hash) SetStrictP(value interface{}, path string) error {
+	p, err := parseSegments(path)
+	if err != nil {
+		return err
+	}
+	if len(p) == 0 {
+		p = []segment{{}}
+	}
+	return h.setSegments(value, p, true)
+}
+
+func (h Hash) SetIfAbsentP(value interface{}, path string) (bool, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return false, err
+	}
+	return h.SetIfAbsent(value, p...)
+}
+
+func (h Hash) SetDefaultP(value interface{}, path string) (interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return h.SetDefault(value, p...)
+}
+
+func (h Hash) DeleteP(path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.Delete(p...)
+}
+
+func (h Hash) GetMapP(path string) (map[string]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	return h.GetMap(p...)
+}
+
+func (h Hash) GetHashP(path string) (Hash, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return NewHash(), err
+	}
+	return h.GetHash(p...)
+}
+
+func (h Hash) GetStringP(path string) (string, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	return h.GetString(p...)
+}
+
+func (h Hash) GetBoolP(path string) (bool, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return false, err
+	}
+	return h.GetBool(p...)
+}
+
+func (h Hash) GetIntP(path string) (int64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return 0, err
+	}
+	return h.GetInt(p...)
+}
+
+func (h Hash) GetFloatP(path string) (float64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return 0, err
+	}
+	return h.GetFloat(p...)
+}
+
+func (h Hash) GetSliceP(path string) ([]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []interface{}{}, err
+	}
+	return h.GetSlice(p...)
+}
+
+func (h Hash) GetIntSliceP(path string) ([]int64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []int64{}, err
+	}
+	return h.GetIntSlice(p...)
+}
+
+func (h Hash) GetFloatSliceP(path string) ([]float64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []float64{}, err
+	}
+	return h.GetFloatSlice(p...)
+}
+
+func (h Hash) GetStringSliceP(path string) ([]string, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []string{}, err
+	}
+	return h.GetStringSlice(p...)
+}
+
+func (h Hash) GetMapSliceP(path string) ([]map[string]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []map[string]interface{}{}, err
+	}
+	return h.GetMapSlice(p...)
+}
+
+func (h Hash) AppendSliceP(val interface{}, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.AppendSlice(val, p...)
+}
+
+func (h Hash) AppendIntSliceP(val int64, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.AppendIntSlice(val, p...)
+}
+
+func (h Hash) AppendFloatSliceP(val float64, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.AppendFloatSlice(val, p...)
+}
+
+func (h Hash) AppendStringSliceP(val string, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.AppendStringSlice(val, p...)
+}
+
+func (h Hash) AppendMapSliceP(val map[string]interface{}, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.AppendMapSlice(val, p...)
+}
+
+func (h Hash) DecodeP(v interface{}, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return h.Decode(v, p...)
+}
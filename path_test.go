@@ -0,0 +1,168 @@
+package zhash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path  string
+		value []string
+		fails bool
+	}{
+		{"", []string{}, false},
+		{"db", []string{"db"}, false},
+		{"db.primary.host", []string{"db", "primary", "host"}, false},
+		{"servers[2].port", []string{"servers", "2", "port"}, false},
+		{"matrix[1][0]", []string{"matrix", "1", "0"}, false},
+		{"[0].name", []string{"0", "name"}, false},
+		{`hosts.example\.com.port`, []string{"hosts", "example.com", "port"}, false},
+		{`keys.a\[1\]`, []string{"keys", "a[1]"}, false},
+		{`back\\slash`, []string{`back\slash`}, false},
+		{"a..b", nil, true},
+		{".a", nil, true},
+		{"a.", nil, true},
+		{"a[", nil, true},
+		{"a[x]", nil, true},
+		{"a[-1]", nil, true},
+		{"a[]", nil, true},
+		{"a]", nil, true},
+		{"a[0]b", nil, true},
+		{"a.[0]", nil, true},
+		{`a\`, nil, true},
+	}
+
+	for i, test := range tests {
+		p, err := ParsePath(test.path)
+		if test.fails {
+			if err == nil {
+				t.Errorf("#%d: ParsePath(%q) doesn't cause error", i, test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: ParsePath(%q) caused error: %v", i, test.path, err)
+		}
+		if !reflect.DeepEqual(p, test.value) {
+			t.Errorf("#%d: ParsePath(%q)=%#v; want %#v", i, test.path, p, test.value)
+		}
+	}
+}
+
+func TestPathPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Path doesn't panic on malformed path")
+		}
+	}()
+	Path("a..b")
+}
+
+func TestFormatPath(t *testing.T) {
+	paths := [][]string{
+		{"db", "primary", "host"},
+		{"hosts", "example.com"},
+		{"a[1]", `b\c`},
+	}
+
+	for i, path := range paths {
+		parsed, err := ParsePath(FormatPath(path))
+		if err != nil {
+			t.Errorf("#%d: ParsePath(FormatPath(%#v)) caused error: %v", i, path, err)
+		}
+		if !reflect.DeepEqual(parsed, path) {
+			t.Errorf("#%d: ParsePath(FormatPath(%#v))=%#v", i, path, parsed)
+		}
+	}
+}
+
+func TestPathAccessors(t *testing.T) {
+	hash := NewHash()
+
+	if err := hash.SetP("localhost", "db.primary.host"); err != nil {
+		t.Errorf("SetP caused error: %v", err)
+	}
+	if err := hash.SetP(10, `hosts.example\.com`); err != nil {
+		t.Errorf("SetP caused error: %v", err)
+	}
+
+	s, err := hash.GetStringP("db.primary.host")
+	if err != nil || s != "localhost" {
+		t.Errorf("GetStringP()=%q, %v; want localhost", s, err)
+	}
+	if v := hash.Get("hosts", "example.com"); v != 10 {
+		t.Errorf("Get(hosts, example.com)=%#v; want 10", v)
+	}
+
+	if err := hash.AppendStringSliceP("a", "list.of.strings"); err != nil {
+		t.Errorf("AppendStringSliceP caused error: %v", err)
+	}
+	sl, err := hash.GetStringSliceP("list.of.strings")
+	if err != nil || !reflect.DeepEqual(sl, []string{"a"}) {
+		t.Errorf("GetStringSliceP()=%#v, %v; want [a]", sl, err)
+	}
+
+	sub, err := hash.GetHashP("db.primary")
+	if err != nil || sub.Len() != 1 {
+		t.Errorf("GetHashP()=%s, %v", sub, err)
+	}
+
+	if err := hash.DeleteP("db.primary.host"); err != nil {
+		t.Errorf("DeleteP caused error: %v", err)
+	}
+	if _, err := hash.GetStringP("db.primary.host"); !IsNotFound(err) {
+		t.Errorf("GetStringP after DeleteP returned %v; want not found", err)
+	}
+
+	if err := hash.SetP(1, "a..b"); err == nil {
+		t.Errorf("SetP with malformed path doesn't cause error")
+	}
+	if _, err := hash.GetIntP("a["); err == nil || IsNotFound(err) {
+		t.Errorf("GetIntP with malformed path returned %v", err)
+	}
+}
+
+func TestSetPCreatesSlices(t *testing.T) {
+	hash := NewHash()
+	if err := hash.SetP(8080, "servers[1].port"); err != nil {
+		t.Errorf("SetP caused error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"servers": []interface{}{
+			nil,
+			map[string]interface{}{"port": 8080},
+		},
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("GetRoot()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	port, err := hash.GetIntP("servers[1].port")
+	if err != nil || port != 8080 {
+		t.Errorf("GetIntP(servers[1].port)=%d, %v; want 8080", port, err)
+	}
+}
+
+func TestSetPIndexLimit(t *testing.T) {
+	hash := NewHash()
+	if err := hash.SetP(1, "a[65536]"); err != nil {
+		t.Errorf("SetP(a[65536]) caused error: %v", err)
+	}
+
+	for _, path := range []string{"a.3000000", "a[9000000000000]", "b[65537]"} {
+		if err := hash.SetP(1, path); err == nil {
+			t.Errorf("SetP(%s) doesn't cause error", path)
+		}
+	}
+	hash.Set(1, "a", "3000000")
+
+	if a, _ := hash.GetSlice("a"); len(a) != 65537 {
+		t.Errorf("len(a)=%d; want 65537", len(a))
+	}
+	if hash.Get("b") != nil {
+		t.Errorf("b=%#v; want nil", hash.Get("b"))
+	}
+}
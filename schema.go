@@ -0,0 +1,554 @@
+package zhash
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Schema describes expected shape of hash values. Schemas are built by
+// SchemaObject, SchemaString and other constructors, and refined by chained
+// methods:
+//
+//	schema := zhash.SchemaObject().
+//		Property("host", zhash.SchemaString().MinLength(1)).
+//		Property("port", zhash.SchemaInteger().Min(1).Max(65535)).
+//		Property("mode", zhash.SchemaString().Enum("dev", "prod")).
+//		Property("tags", zhash.SchemaArray(zhash.SchemaString()).MaxItems(10)).
+//		Required("host", "port")
+//
+// Schema can be loaded from JSON Schema document via LoadSchema too.
+type Schema struct {
+	kind schemaKind
+
+	properties map[string]*Schema
+	required   []string
+	additional *Schema
+	closed     bool
+
+	items    *Schema
+	minItems *int
+	maxItems *int
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	enum []interface{}
+}
+
+type schemaKind int
+
+const (
+	kindAny schemaKind = iota
+	kindString
+	kindInteger
+	kindNumber
+	kindBoolean
+	kindNull
+	kindArray
+	kindObject
+	kindNothing
+)
+
+var schemaKindNames = map[schemaKind]string{
+	kindAny:     "any",
+	kindString:  "string",
+	kindInteger: "integer",
+	kindNumber:  "number",
+	kindBoolean: "boolean",
+	kindNull:    "null",
+	kindArray:   "array",
+	kindObject:  "object",
+	kindNothing: "no value",
+}
+
+// Returns schema accepting any value.
+func SchemaAny() *Schema {
+	return &Schema{kind: kindAny}
+}
+
+// Returns schema accepting strings.
+func SchemaString() *Schema {
+	return &Schema{kind: kindString}
+}
+
+// Returns schema accepting integer numbers of any type, including floats
+// without fractional part.
+func SchemaInteger() *Schema {
+	return &Schema{kind: kindInteger}
+}
+
+// Returns schema accepting numbers of any type.
+func SchemaNumber() *Schema {
+	return &Schema{kind: kindNumber}
+}
+
+// Returns schema accepting bools.
+func SchemaBoolean() *Schema {
+	return &Schema{kind: kindBoolean}
+}
+
+// Returns schema accepting nil only.
+func SchemaNull() *Schema {
+	return &Schema{kind: kindNull}
+}
+
+// Returns schema accepting slices. If items is not nil, every element must
+// match it.
+func SchemaArray(items *Schema) *Schema {
+	return &Schema{kind: kindArray, items: items}
+}
+
+// Returns schema accepting maps. Keys are described by Property, keys not
+// described are allowed unless AdditionalProperties or Closed is used.
+func SchemaObject() *Schema {
+	return &Schema{kind: kindObject, properties: map[string]*Schema{}}
+}
+
+// Describes value under key of object.
+func (s *Schema) Property(key string, schema *Schema) *Schema {
+	if s.properties == nil {
+		s.properties = map[string]*Schema{}
+	}
+	s.properties[key] = schema
+	return s
+}
+
+// Marks keys of object as required.
+func (s *Schema) Required(keys ...string) *Schema {
+	s.required = append(s.required, keys...)
+	return s
+}
+
+// Sets schema for values under keys not described by Property.
+func (s *Schema) AdditionalProperties(schema *Schema) *Schema {
+	s.additional = schema
+	s.closed = false
+	return s
+}
+
+// Forbids keys not described by Property.
+func (s *Schema) Closed() *Schema {
+	s.additional = nil
+	s.closed = true
+	return s
+}
+
+// Sets schema for every element of array.
+func (s *Schema) Items(schema *Schema) *Schema {
+	s.items = schema
+	return s
+}
+
+// Sets minimal number of array elements.
+func (s *Schema) MinItems(n int) *Schema {
+	s.minItems = &n
+	return s
+}
+
+// Sets maximal number of array elements.
+func (s *Schema) MaxItems(n int) *Schema {
+	s.maxItems = &n
+	return s
+}
+
+// Sets inclusive lower bound of number.
+func (s *Schema) Min(min float64) *Schema {
+	s.minimum = &min
+	return s
+}
+
+// Sets inclusive upper bound of number.
+func (s *Schema) Max(max float64) *Schema {
+	s.maximum = &max
+	return s
+}
+
+// Sets exclusive lower bound of number.
+func (s *Schema) ExclusiveMin(min float64) *Schema {
+	s.exclusiveMinimum = &min
+	return s
+}
+
+// Sets exclusive upper bound of number.
+func (s *Schema) ExclusiveMax(max float64) *Schema {
+	s.exclusiveMaximum = &max
+	return s
+}
+
+// Sets minimal length of string in characters.
+func (s *Schema) MinLength(n int) *Schema {
+	s.minLength = &n
+	return s
+}
+
+// Sets maximal length of string in characters.
+func (s *Schema) MaxLength(n int) *Schema {
+	s.maxLength = &n
+	return s
+}
+
+// Sets regular expression string must match. Like in JSON Schema, pattern
+// is not anchored. Panics if pattern can not be compiled.
+func (s *Schema) Pattern(pattern string) *Schema {
+	s.pattern = regexp.MustCompile(pattern)
+	return s
+}
+
+// Restricts value to one of given values. Numbers are compared by value
+// whatever their types are.
+func (s *Schema) Enum(values ...interface{}) *Schema {
+	s.enum = values
+	return s
+}
+
+// ValidationError is returned by Validate and lists every violation found.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf(
+		"%d validation error(s): %s",
+		len(e.Errors), strings.Join(messages, "; "),
+	)
+}
+
+// Validates whole hash against schema. Returns *ValidationError listing all
+// violations with their paths, or nil if hash matches the schema.
+func (h Hash) Validate(schema *Schema) error {
+	root, err := h.rootValue()
+	if err != nil {
+		return err
+	}
+
+	v := validator{}
+	v.validate(schema, root, []string{})
+	if len(v.errors) > 0 {
+		return &ValidationError{v.errors}
+	}
+	return nil
+}
+
+type validator struct {
+	errors []*FieldError
+}
+
+func (v *validator) fail(path []string, format string, args ...interface{}) {
+	v.errors = append(v.errors, &FieldError{
+		Path: append([]string{}, path...),
+		Err:  fmt.Errorf(format, args...),
+	})
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path []string) {
+	if s == nil {
+		return
+	}
+
+	if !s.kind.matches(value) {
+		v.fail(path, "expected %s, got %s", schemaKindNames[s.kind], valueKind(value))
+		return
+	}
+
+	if len(s.enum) > 0 && !s.inEnum(value) {
+		v.fail(path, "%s is not one of %s", formatValue(value), formatValue(s.enum))
+	}
+
+	if number, ok := toNumber(value); ok {
+		v.validateNumber(s, number, path)
+	}
+
+	if str, ok := value.(string); ok {
+		v.validateString(s, str, path)
+	}
+
+	if m, ok := toStringMap(value); ok {
+		v.validateObject(s, m, path)
+	}
+
+	if slice, ok := asSlice(value); ok {
+		v.validateArray(s, slice, path)
+	}
+}
+
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, allowed := range s.enum {
+		if valuesEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k schemaKind) matches(value interface{}) bool {
+	switch k {
+	case kindAny:
+		return true
+	case kindNothing:
+		return false
+	case kindNull:
+		return value == nil
+	case kindInteger:
+		number, ok := toNumber(value)
+		return ok && number == math.Trunc(number)
+	}
+	return schemaKindNames[k] == valueKind(value)
+}
+
+func (v *validator) validateNumber(s *Schema, number float64, path []string) {
+	if s.minimum != nil && number < *s.minimum {
+		v.fail(path, "%v is less than minimum %v", number, *s.minimum)
+	}
+	if s.maximum != nil && number > *s.maximum {
+		v.fail(path, "%v is greater than maximum %v", number, *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && number <= *s.exclusiveMinimum {
+		v.fail(path, "%v is not greater than %v", number, *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && number >= *s.exclusiveMaximum {
+		v.fail(path, "%v is not less than %v", number, *s.exclusiveMaximum)
+	}
+}
+
+func (v *validator) validateString(s *Schema, str string, path []string) {
+	length := len([]rune(str))
+	if s.minLength != nil && length < *s.minLength {
+		v.fail(path, "length %d is less than minLength %d", length, *s.minLength)
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		v.fail(path, "length %d is greater than maxLength %d", length, *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		v.fail(path, "%q does not match pattern %q", str, s.pattern)
+	}
+}
+
+func (v *validator) validateObject(
+	s *Schema, m map[string]interface{}, path []string,
+) {
+	for _, key := range s.required {
+		if _, ok := m[key]; !ok {
+			v.fail(append(path, key), "required key is missing")
+		}
+	}
+
+	for _, key := range sortedKeys(m) {
+		if property, ok := s.properties[key]; ok {
+			v.validate(property, m[key], append(path, key))
+			continue
+		}
+
+		switch {
+		case s.closed:
+			v.fail(append(path, key), "unexpected key")
+		case s.additional != nil:
+			v.validate(s.additional, m[key], append(path, key))
+		}
+	}
+}
+
+func (v *validator) validateArray(s *Schema, slice reflect.Value, path []string) {
+	if s.minItems != nil && slice.Len() < *s.minItems {
+		v.fail(path, "has %d items, expected at least %d", slice.Len(), *s.minItems)
+	}
+	if s.maxItems != nil && slice.Len() > *s.maxItems {
+		v.fail(path, "has %d items, expected at most %d", slice.Len(), *s.maxItems)
+	}
+
+	if s.items == nil {
+		return
+	}
+	for i := 0; i < slice.Len(); i++ {
+		v.validate(
+			s.items, slice.Index(i).Interface(), append(path, fmt.Sprint(i)),
+		)
+	}
+}
+
+// Loads schema from JSON Schema (draft 2020-12) document read into hash, for
+// example by ReadHash. Supported keywords are type (single one), properties,
+// required, additionalProperties, items, minItems, maxItems, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern
+// and enum. Annotations like title, description or default are ignored,
+// other keywords cause an error.
+func LoadSchema(h Hash) (*Schema, error) {
+	return loadSchema(h.data, []string{})
+}
+
+var schemaTypes = map[string]schemaKind{
+	"string":  kindString,
+	"integer": kindInteger,
+	"number":  kindNumber,
+	"boolean": kindBoolean,
+	"null":    kindNull,
+	"array":   kindArray,
+	"object":  kindObject,
+}
+
+var schemaAnnotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+	"deprecated":  true,
+	"readOnly":    true,
+	"writeOnly":   true,
+}
+
+func loadSchema(node interface{}, path []string) (*Schema, error) {
+	if b, ok := node.(bool); ok {
+		// true schema accepts anything, false schema accepts nothing
+		if b {
+			return SchemaAny(), nil
+		}
+		return &Schema{kind: kindNothing}, nil
+	}
+
+	m, ok := toStringMap(node)
+	if !ok {
+		return nil, &FieldError{path, fmt.Errorf(
+			"schema must be object or bool, got %s", valueKind(node),
+		)}
+	}
+
+	s := SchemaAny()
+	var err error
+	fail := func(key string, format string, args ...interface{}) error {
+		return &FieldError{
+			append(append([]string{}, path...), key),
+			fmt.Errorf(format, args...),
+		}
+	}
+
+	for _, key := range sortedKeys(m) {
+		value := m[key]
+		switch key {
+		case "type":
+			name, _ := value.(string)
+			kind, ok := schemaTypes[name]
+			if !ok {
+				return nil, fail(key, "unsupported type %s", formatValue(value))
+			}
+			s.kind = kind
+
+		case "properties":
+			properties, ok := toStringMap(value)
+			if !ok {
+				return nil, fail(key, "expected object")
+			}
+			for _, name := range sortedKeys(properties) {
+				property, err := loadSchema(
+					properties[name], append(path, key, name),
+				)
+				if err != nil {
+					return nil, err
+				}
+				s.Property(name, property)
+			}
+
+		case "required":
+			list, ok := asSlice(value)
+			if !ok {
+				return nil, fail(key, "expected array of strings")
+			}
+			for _, elem := range toInterfaceSlice(list) {
+				name, ok := elem.(string)
+				if !ok {
+					return nil, fail(key, "expected array of strings")
+				}
+				s.Required(name)
+			}
+
+		case "additionalProperties":
+			if b, ok := value.(bool); ok {
+				if !b {
+					s.Closed()
+				}
+				continue
+			}
+			s.additional, err = loadSchema(value, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+
+		case "items":
+			s.items, err = loadSchema(value, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			number, ok := toNumber(value)
+			if !ok {
+				return nil, fail(key, "expected number")
+			}
+			switch key {
+			case "minimum":
+				s.Min(number)
+			case "maximum":
+				s.Max(number)
+			case "exclusiveMinimum":
+				s.ExclusiveMin(number)
+			case "exclusiveMaximum":
+				s.ExclusiveMax(number)
+			}
+
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := coerceInt[int](value)
+			if err != nil || n < 0 {
+				return nil, fail(key, "expected non negative integer")
+			}
+			switch key {
+			case "minLength":
+				s.MinLength(n)
+			case "maxLength":
+				s.MaxLength(n)
+			case "minItems":
+				s.MinItems(n)
+			case "maxItems":
+				s.MaxItems(n)
+			}
+
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, fail(key, "expected string")
+			}
+			s.pattern, err = regexp.Compile(pattern)
+			if err != nil {
+				return nil, fail(key, "%w", err)
+			}
+
+		case "enum":
+			values, ok := asSlice(value)
+			if !ok {
+				return nil, fail(key, "expected array")
+			}
+			s.Enum(toInterfaceSlice(values)...)
+
+		default:
+			if !schemaAnnotations[key] {
+				return nil, fail(key, "unsupported keyword")
+			}
+		}
+	}
+
+	return s, nil
+}
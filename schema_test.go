@@ -0,0 +1,205 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func schemaBase() Hash {
+	return HashFromMap(map[string]interface{}{
+		"host": "",
+		"port": 70000,
+		"mode": "test",
+		"tags": []interface{}{"a", 1, "b"},
+		"db": map[interface{}]interface{}{
+			"user":    "admin",
+			"timeout": 1.5,
+		},
+		"extra": true,
+	})
+}
+
+func validationErrors(t *testing.T, err error) map[string]string {
+	if err == nil {
+		return map[string]string{}
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate()=%v; want *ValidationError", err)
+	}
+
+	result := map[string]string{}
+	for _, fieldErr := range validationErr.Errors {
+		result[FormatPath(fieldErr.Path)] = fieldErr.Err.Error()
+	}
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	schema := SchemaObject().
+		Property("host", SchemaString().MinLength(1)).
+		Property("port", SchemaInteger().Min(1).Max(65535)).
+		Property("mode", SchemaString().Enum("dev", "prod")).
+		Property("tags", SchemaArray(SchemaString()).MaxItems(2)).
+		Property("db", SchemaObject().
+			Property("user", SchemaString().Pattern("^[a-z]+$")).
+			Property("timeout", SchemaInteger()).
+			Property("password", SchemaString()).
+			Required("password").
+			Closed(),
+		).
+		Required("host", "name")
+
+	expected := map[string]string{
+		"host":        "length 0 is less than minLength 1",
+		"port":        "70000 is greater than maximum 65535",
+		"mode":        `"test" is not one of ["dev","prod"]`,
+		"tags":        "has 3 items, expected at most 2",
+		"tags.1":      "expected string, got number",
+		"db.timeout":  "expected integer, got number",
+		"db.password": "required key is missing",
+		"name":        "required key is missing",
+	}
+
+	errs := validationErrors(t, schemaBase().Validate(schema))
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("Validate()=%#v; want %#v", errs, expected)
+	}
+
+	valid := HashFromMap(map[string]interface{}{
+		"host": "localhost",
+		"name": "app",
+		"port": 8080.0,
+		"mode": "prod",
+		"db": map[string]interface{}{
+			"user":     "admin",
+			"password": "secret",
+			"timeout":  int64(10),
+		},
+	})
+	if err := valid.Validate(schema); err != nil {
+		t.Errorf("Validate() of valid hash=%v", err)
+	}
+}
+
+func TestValidateAdditionalProperties(t *testing.T) {
+	schema := SchemaObject().
+		Property("name", SchemaString()).
+		AdditionalProperties(SchemaInteger().ExclusiveMin(0))
+
+	h := HashFromMap(map[string]interface{}{
+		"name": "x",
+		"a":    1,
+		"b":    0,
+		"c":    "x",
+	})
+
+	expected := map[string]string{
+		"b": "0 is not greater than 0",
+		"c": "expected integer, got string",
+	}
+
+	errs := validationErrors(t, h.Validate(schema))
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("Validate()=%#v; want %#v", errs, expected)
+	}
+
+	errs = validationErrors(t, h.Validate(SchemaObject().Closed()))
+	if len(errs) != 4 || errs["a"] != "unexpected key" {
+		t.Errorf("Validate(Closed())=%#v", errs)
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	document := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "config",
+		"type": "object",
+		"properties": {
+			"host": {"type": "string", "minLength": 1},
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+			"mode": {"enum": ["dev", "prod"]},
+			"tags": {
+				"type": "array",
+				"items": {"type": "string"},
+				"maxItems": 2
+			},
+			"db": {
+				"type": "object",
+				"properties": {
+					"user": {"type": "string", "pattern": "^[a-z]+$"},
+					"timeout": {"type": "integer"},
+					"password": {"type": "string"}
+				},
+				"required": ["password"],
+				"additionalProperties": false
+			},
+			"extra": false
+		},
+		"required": ["host", "name"]
+	}`
+
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	if err := h.ReadHash(bytes.NewBufferString(document)); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadSchema(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"host":        "length 0 is less than minLength 1",
+		"port":        "70000 is greater than maximum 65535",
+		"mode":        `"test" is not one of ["dev","prod"]`,
+		"tags":        "has 3 items, expected at most 2",
+		"tags.1":      "expected string, got number",
+		"db.timeout":  "expected integer, got number",
+		"db.password": "required key is missing",
+		"name":        "required key is missing",
+		"extra":       "expected no value, got boolean",
+	}
+
+	errs := validationErrors(t, schemaBase().Validate(schema))
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("Validate()=%#v; want %#v", errs, expected)
+	}
+}
+
+func TestLoadSchemaFails(t *testing.T) {
+	tests := []struct {
+		schema map[string]interface{}
+		path   string
+	}{
+		{map[string]interface{}{"$ref": "#/defs/x"}, "$ref"},
+		{map[string]interface{}{"type": []interface{}{"string", "null"}}, "type"},
+		{map[string]interface{}{"minimum": "1"}, "minimum"},
+		{map[string]interface{}{"maxLength": -1}, "maxLength"},
+		{map[string]interface{}{"pattern": "("}, "pattern"},
+		{map[string]interface{}{"required": []interface{}{1}}, "required"},
+		{map[string]interface{}{
+			"properties": map[string]interface{}{"a": 1},
+		}, "properties.a"},
+		{map[string]interface{}{
+			"items": map[string]interface{}{"type": "date"},
+		}, "items.type"},
+	}
+
+	for i, test := range tests {
+		_, err := LoadSchema(HashFromMap(test.schema))
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Errorf("#%d: LoadSchema()=%v; want *FieldError", i, err)
+			continue
+		}
+		if path := FormatPath(fieldErr.Path); path != test.path {
+			t.Errorf("#%d: LoadSchema() failed at %s; want %s", i, path, test.path)
+		}
+	}
+}
@@ -123,6 +123,40 @@ deploy:
 	for i := 0; i < 3; i++ {
 		t.Log(result[i]["path"])
 	}
+
+	if _, err := hash.GetMapSlice("deploy", "framework"); err == nil {
+		t.Errorf("GetMapSlice of int doesn't fail")
+	}
+	if _, err := hash.GetMapSlice("deploy", "chmod", "0", "path"); err == nil {
+		t.Errorf("GetMapSlice of string doesn't fail")
+	}
+
+	hash.Set([]interface{}{"skipped", map[string]interface{}{"a": 1}, nil}, "mixed")
+	mixed, err := hash.GetMapSlice("mixed")
+	expected := []map[string]interface{}{{"a": 1}}
+	if err != nil || !reflect.DeepEqual(mixed, expected) {
+		t.Errorf("GetMapSlice(mixed)=%#v, %v; want %#v", mixed, err, expected)
+	}
+}
+
+func TestAppendMapSlice(t *testing.T) {
+	hash := NewHash()
+	for _, name := range []string{"a", "b"} {
+		err := hash.AppendMapSlice(map[string]interface{}{"name": name}, "servers")
+		if err != nil {
+			t.Fatalf("AppendMapSlice(%s) fails: %s", name, err)
+		}
+	}
+
+	result, err := hash.GetMapSlice("servers")
+	if err != nil {
+		t.Fatalf("GetMapSlice fails: %s", err)
+	}
+
+	expected := []map[string]interface{}{{"name": "a"}, {"name": "b"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("GetMapSlice()=%#v; want %#v", result, expected)
+	}
 }
 
 type appendSliceTest struct {
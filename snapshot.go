@@ -0,0 +1,182 @@
+package zhash
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Snapshot is a read-only deep copy of Hash. It can be shared between
+// goroutines without any locking: snapshot data is never changed, and maps
+// and slices are returned as copies.
+//
+// Scalars are returned as is, but every read of a map or slice copies the
+// whole subtree, and Hash and GetHash copy even more. Use View to read
+// large subtrees, or many values at once, without copying.
+type Snapshot struct {
+	hash Hash
+}
+
+// Returns read-only deep copy of h. Marshaller, unmarshaller and coercion
+// mode are kept.
+func (h Hash) Snapshot() *Snapshot {
+	if h.data == nil {
+		h.data = map[string]interface{}{}
+	}
+	return &Snapshot{hash: h.deepCopy()}
+}
+
+// Returns mutable deep copy of snapshot.
+func (s *Snapshot) Hash() Hash {
+	return s.hash.deepCopy()
+}
+
+// Calls fn with underlying hash without copying it. Hash must not be
+// changed or retained by fn, and neither must values got from it.
+func (s *Snapshot) View(fn func(h Hash)) {
+	fn(s.hash)
+}
+
+func (s *Snapshot) WriteHash(w io.Writer) error {
+	return s.hash.WriteHash(w)
+}
+
+func (s *Snapshot) String() string {
+	return s.hash.String()
+}
+
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	return s.hash.MarshalJSON()
+}
+
+// Retrieves value under given path, see Hash.Get. Maps and slices are
+// returned as deep copies.
+func (s *Snapshot) Get(path ...string) interface{} {
+	value := s.hash.Get(path...)
+	switch value.(type) {
+	case nil, string, bool, int, int64, float64:
+		return value
+	}
+	return copyValue(value)
+}
+
+func (s *Snapshot) GetMap(path ...string) (map[string]interface{}, error) {
+	m, err := s.hash.GetMap(path...)
+	return copyValue(m).(map[string]interface{}), err
+}
+
+// Returns mutable copy of subtree under given path, see Hash.GetHash.
+func (s *Snapshot) GetHash(path ...string) (Hash, error) {
+	h, err := s.hash.GetHash(path...)
+	return h.deepCopy(), err
+}
+
+func (s *Snapshot) Keys() []string {
+	return s.hash.Keys()
+}
+
+func (s *Snapshot) Len() int {
+	return s.hash.Len()
+}
+
+func (s *Snapshot) GetString(path ...string) (string, error) {
+	return s.hash.GetString(path...)
+}
+
+func (s *Snapshot) GetBool(path ...string) (bool, error) {
+	return s.hash.GetBool(path...)
+}
+
+func (s *Snapshot) GetInt(path ...string) (int64, error) {
+	return s.hash.GetInt(path...)
+}
+
+func (s *Snapshot) GetFloat(path ...string) (float64, error) {
+	return s.hash.GetFloat(path...)
+}
+
+func (s *Snapshot) GetSlice(path ...string) ([]interface{}, error) {
+	slice, err := s.hash.GetSlice(path...)
+	return copyValue(slice).([]interface{}), err
+}
+
+func (s *Snapshot) GetIntSlice(path ...string) ([]int64, error) {
+	slice, err := s.hash.GetIntSlice(path...)
+	return append([]int64{}, slice...), err
+}
+
+func (s *Snapshot) GetFloatSlice(path ...string) ([]float64, error) {
+	slice, err := s.hash.GetFloatSlice(path...)
+	return append([]float64{}, slice...), err
+}
+
+func (s *Snapshot) GetStringSlice(path ...string) ([]string, error) {
+	slice, err := s.hash.GetStringSlice(path...)
+	return append([]string{}, slice...), err
+}
+
+func (s *Snapshot) GetMapSlice(path ...string) ([]map[string]interface{}, error) {
+	slice, err := s.hash.GetMapSlice(path...)
+	result := make([]map[string]interface{}, len(slice))
+	for i, m := range slice {
+		result[i] = copyValue(m).(map[string]interface{})
+	}
+	return result, err
+}
+
+// Decodes subtree under given path into v, see Hash.Decode. Maps and slices
+// are decoded into interface fields as copies.
+func (s *Snapshot) Decode(v interface{}, path ...string) error {
+	return s.hash.decode(v, path, true)
+}
+
+// Holder keeps current Snapshot and replaces it atomically, so readers
+// always see either old or new snapshot and never a half loaded one.
+//
+//	holder := zhash.NewHolder(h)
+//	go func() {
+//		for range reload {
+//			if err := holder.ReadHash(fd); err != nil {
+//				log.Print(err)
+//			}
+//		}
+//	}()
+//
+//	port, err := holder.Load().GetInt("server", "port")
+type Holder struct {
+	current atomic.Value
+}
+
+// Creates Holder keeping snapshot of h.
+func NewHolder(h Hash) *Holder {
+	holder := &Holder{}
+	holder.current.Store(h.Snapshot())
+	return holder
+}
+
+// Returns current snapshot.
+func (h *Holder) Load() *Snapshot {
+	return h.current.Load().(*Snapshot)
+}
+
+// Replaces current snapshot by s.
+func (h *Holder) Store(s *Snapshot) {
+	h.current.Store(s)
+}
+
+// Replaces current snapshot by s and returns the previous one.
+func (h *Holder) Swap(s *Snapshot) *Snapshot {
+	return h.current.Swap(s).(*Snapshot)
+}
+
+// Reads fresh hash from r using unmarshaller of current snapshot, and makes
+// it current if reading succeeds. Current snapshot is kept otherwise.
+func (h *Holder) ReadHash(r io.Reader) error {
+	fresh := h.Load().hash.emptyCopy()
+
+	if err := fresh.ReadHash(r); err != nil {
+		return err
+	}
+
+	h.Store(&Snapshot{hash: fresh.deepCopy()})
+	return nil
+}
@@ -0,0 +1,125 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	h := HashFromMap(map[string]interface{}{
+		"db":    map[interface{}]interface{}{"host": "localhost"},
+		"ports": []int64{80, 443},
+	})
+	snapshot := h.Snapshot()
+
+	h.Set("changed", "db", "host")
+	h.Set(8080, "ports", "0")
+
+	m, _ := snapshot.GetMap("db")
+	m["host"] = "changed"
+	ports, _ := snapshot.GetIntSlice("ports")
+	ports[1] = 8443
+	copied := snapshot.Hash()
+	copied.Set("changed", "db", "host")
+
+	expected := map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost"},
+		"ports": []int64{80, 443},
+	}
+	if root := snapshot.Hash().GetRoot(); !reflect.DeepEqual(root, expected) {
+		t.Errorf("snapshot changed: %#v", root)
+	}
+	if host, _ := snapshot.GetString("db", "host"); host != "localhost" {
+		t.Errorf("GetString()=%q; want localhost", host)
+	}
+	if host := snapshot.Get("db", "host"); host != "localhost" {
+		t.Errorf("Get()=%#v; want localhost", host)
+	}
+
+	snapshot.View(func(h Hash) {
+		if port, _ := h.GetInt("ports", "1"); port != 443 {
+			t.Errorf("View: GetInt()=%d; want 443", port)
+		}
+	})
+}
+
+func TestSnapshotDecode(t *testing.T) {
+	snapshot := HashFromMap(map[string]interface{}{
+		"extra": map[string]interface{}{
+			"a": map[string]interface{}{"b": 1},
+		},
+	}).Snapshot()
+
+	var cfg struct {
+		Extra map[string]interface{} `zhash:"extra"`
+	}
+	if err := snapshot.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Extra["a"].(map[string]interface{})["b"] = 2
+
+	if b := snapshot.Get("extra", "a", "b"); b != 1 {
+		t.Errorf("Get(extra, a, b)=%#v; want 1", b)
+	}
+}
+
+func TestHolder(t *testing.T) {
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	h.Set(1.0, "version")
+	holder := NewHolder(h)
+
+	if err := holder.ReadHash(bytes.NewBufferString(`{"version":`)); err == nil {
+		t.Error("ReadHash of corrupted json does not fail")
+	}
+	if v, _ := holder.Load().GetFloat("version"); v != 1 {
+		t.Errorf("failed ReadHash changed version to %v", v)
+	}
+
+	if err := holder.ReadHash(bytes.NewBufferString(`{"version": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := holder.Load().GetFloat("version"); v != 2 {
+		t.Errorf("version=%v; want 2", v)
+	}
+
+	next := NewHash()
+	next.Set(3.0, "version")
+	previous := holder.Swap(next.Snapshot())
+	if v, _ := previous.GetFloat("version"); v != 2 {
+		t.Errorf("Swap returned version %v; want 2", v)
+	}
+	if v, _ := holder.Load().GetFloat("version"); v != 3 {
+		t.Errorf("version=%v; want 3", v)
+	}
+}
+
+func TestHolderConcurrent(t *testing.T) {
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	holder := NewHolder(h)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			holder.ReadHash(bytes.NewBufferString(`{"a": 1, "b": 1}`))
+			holder.ReadHash(bytes.NewBufferString(`{"a": 2, "b": 2}`))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			snapshot := holder.Load()
+			if snapshot.Get("a") != snapshot.Get("b") {
+				t.Errorf("half loaded snapshot: %s", snapshot)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,651 @@
+package zhash
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// SyncHash is a Hash safe for concurrent use. Every method takes read or
+// write lock, and maps and slices are returned as copies, so they can be
+// used after the lock is released. Yaml maps are normalized when stored.
+//
+// SyncHash has the same methods as Hash except GetRoot, which would expose
+// internal state (use View or Hash instead), and Normalize and SetNormalize,
+// as data is always normalized.
+type SyncHash struct {
+	mu   sync.RWMutex
+	hash Hash
+}
+
+// Creates SyncHash holding deep copy of h, so later changes of h are not
+// seen by SyncHash. Marshaller, unmarshaller and coercion mode are kept.
+func NewSyncHash(h Hash) *SyncHash {
+	if h.data == nil {
+		h.data = map[string]interface{}{}
+	}
+	return &SyncHash{hash: h.deepCopy()}
+}
+
+// Calls fn with underlying hash under read lock. Hash must not be changed or
+// retained by fn.
+func (s *SyncHash) View(fn func(h Hash)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.hash)
+}
+
+// Calls fn with underlying hash under write lock, so several changes can be
+// done atomically. Hash must not be retained by fn.
+func (s *SyncHash) Do(fn func(h *Hash) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defer s.hash.Normalize()
+	return fn(&s.hash)
+}
+
+// Returns deep copy of the whole hash.
+func (s *SyncHash) Hash() Hash {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.deepCopy()
+}
+
+func (s *SyncHash) SetCoercion(c Coercion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetCoercion(c)
+}
+
+func (s *SyncHash) SetFormat(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetFormat(name)
+}
+
+func (s *SyncHash) SetInterpolation(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetInterpolation(enabled)
+}
+
+func (s *SyncHash) SetIncludeKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetIncludeKey(key)
+}
+
+func (s *SyncHash) SetMarshallerFunc(fu Marshaller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetMarshallerFunc(fu)
+}
+
+func (s *SyncHash) SetUnmarshallerFunc(fu Unmarshaller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetUnmarshallerFunc(fu)
+}
+
+// Reads hash from r like Hash.ReadHash. Data is unmarshalled into a fresh
+// hash, which replaces current content only if reading succeeds, and
+// OnChange subscribers are notified as by SetRoot.
+func (s *SyncHash) ReadHash(r io.Reader) error {
+	return s.readFresh(func(h *Hash) error {
+		return h.ReadHash(r)
+	})
+}
+
+// Reads hash from r like Hash.ReadHashAs, replacing content like ReadHash.
+func (s *SyncHash) ReadHashAs(r io.Reader, name string) error {
+	return s.readFresh(func(h *Hash) error {
+		return h.ReadHashAs(r, name)
+	})
+}
+
+// Reads hash from r like Hash.ReadHashAuto, replacing content like
+// ReadHash.
+func (s *SyncHash) ReadHashAuto(r io.Reader) error {
+	return s.readFresh(func(h *Hash) error {
+		return h.ReadHashAuto(r)
+	})
+}
+
+// Reads hash from file like Hash.ReadFile, replacing content like ReadHash.
+func (s *SyncHash) ReadFile(path string) error {
+	return s.readFresh(func(h *Hash) error {
+		return h.ReadFile(path)
+	})
+}
+
+// readFresh reads data into a fresh hash without holding the lock, and
+// replaces content and format of s by it if read succeeds.
+func (s *SyncHash) readFresh(read func(h *Hash) error) error {
+	s.mu.RLock()
+	fresh := s.hash.emptyCopy()
+	s.mu.RUnlock()
+
+	if err := read(&fresh); err != nil {
+		return err
+	}
+	fresh.Normalize()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.marshal, s.hash.unmarshal = fresh.marshal, fresh.unmarshal
+	s.hash.SetRoot(fresh.data)
+	return nil
+}
+
+func (s *SyncHash) WriteFile(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.WriteFile(path)
+}
+
+func (s *SyncHash) WriteFileAtomic(
+	path string, perm os.FileMode, opts ...WriteOption,
+) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.WriteFileAtomic(path, perm, opts...)
+}
+
+func (s *SyncHash) Reader() (io.Reader, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.Reader()
+}
+
+func (s *SyncHash) WriteHash(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.WriteHash(w)
+}
+
+func (s *SyncHash) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.String()
+}
+
+func (s *SyncHash) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.MarshalJSON()
+}
+
+// Retrieves copy of value under given path, see Hash.Get.
+func (s *SyncHash) Get(path ...string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyValue(s.hash.Get(path...))
+}
+
+func (s *SyncHash) GetMap(path ...string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, err := s.hash.GetMap(path...)
+	return copyValue(m).(map[string]interface{}), err
+}
+
+// Returns copy of subtree under given path as a separate Hash, see
+// Hash.GetHash.
+func (s *SyncHash) GetHash(path ...string) (Hash, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h, err := s.hash.GetHash(path...)
+	return h.deepCopy(), err
+}
+
+func (s *SyncHash) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.Keys()
+}
+
+func (s *SyncHash) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.Len()
+}
+
+func (s *SyncHash) GetString(path ...string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetString(path...)
+}
+
+func (s *SyncHash) GetBool(path ...string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetBool(path...)
+}
+
+func (s *SyncHash) GetInt(path ...string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetInt(path...)
+}
+
+func (s *SyncHash) GetFloat(path ...string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetFloat(path...)
+}
+
+func (s *SyncHash) GetSlice(path ...string) ([]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slice, err := s.hash.GetSlice(path...)
+	return copyValue(slice).([]interface{}), err
+}
+
+func (s *SyncHash) GetIntSlice(path ...string) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slice, err := s.hash.GetIntSlice(path...)
+	return append([]int64{}, slice...), err
+}
+
+func (s *SyncHash) GetFloatSlice(path ...string) ([]float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slice, err := s.hash.GetFloatSlice(path...)
+	return append([]float64{}, slice...), err
+}
+
+func (s *SyncHash) GetStringSlice(path ...string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slice, err := s.hash.GetStringSlice(path...)
+	return append([]string{}, slice...), err
+}
+
+func (s *SyncHash) GetMapSlice(path ...string) ([]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	slice, err := s.hash.GetMapSlice(path...)
+	result := make([]map[string]interface{}, len(slice))
+	for i, m := range slice {
+		result[i] = copyValue(m).(map[string]interface{})
+	}
+	return result, err
+}
+
+// Decodes subtree under given path into v, see Hash.Decode. Maps and slices
+// are decoded into interface fields as copies.
+func (s *SyncHash) Decode(v interface{}, path ...string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.decode(v, path, true)
+}
+
+// Sets copy of value under given path, see Hash.Set.
+func (s *SyncHash) Set(value interface{}, path ...string) {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.Set(value, path...)
+}
+
+func (s *SyncHash) SetStrict(value interface{}, path ...string) error {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetStrict(value, path...)
+}
+
+func (s *SyncHash) SetIfAbsent(value interface{}, path ...string) (bool, error) {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetIfAbsent(value, path...)
+}
+
+func (s *SyncHash) SetDefault(
+	value interface{}, path ...string,
+) (interface{}, error) {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, err := s.hash.SetDefault(value, path...)
+	return copyValue(result), err
+}
+
+func (s *SyncHash) Delete(path ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.Delete(path...)
+}
+
+func (s *SyncHash) AppendSlice(val interface{}, path ...string) error {
+	val = copyValue(val)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendSlice(val, path...)
+}
+
+func (s *SyncHash) AppendIntSlice(val int64, path ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendIntSlice(val, path...)
+}
+
+func (s *SyncHash) AppendFloatSlice(val float64, path ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendFloatSlice(val, path...)
+}
+
+func (s *SyncHash) AppendStringSlice(val string, path ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendStringSlice(val, path...)
+}
+
+func (s *SyncHash) AppendMapSlice(
+	val map[string]interface{}, path ...string,
+) error {
+	val = copyValue(val).(map[string]interface{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendMapSlice(val, path...)
+}
+
+// Merges other into hash, see Hash.Merge.
+func (s *SyncHash) Merge(other Hash, opts ...MergeOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.Merge(other, opts...)
+}
+
+func (s *SyncHash) MergePatch(patch Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.MergePatch(patch)
+}
+
+func (s *SyncHash) ApplyPatch(ops []PatchOp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.ApplyPatch(ops)
+}
+
+// Subscribes fn to changes of value under path pattern, see Hash.OnChange.
+// Fn is called under write lock, so it must not use s.
+func (s *SyncHash) OnChange(
+	pattern string, fn func(old, new interface{}),
+) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.OnChange(pattern, fn)
+}
+
+// Subscribes fn to changes like OnChange does, see Hash.OnChangePath.
+func (s *SyncHash) OnChangePath(
+	pattern string, fn func(path []string, old, new interface{}),
+) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.OnChangePath(pattern, fn)
+}
+
+// Atomically replaces value under given path by result of fn. Fn gets copy
+// of current value, or nil if there is no value, and is called under write
+// lock, so it must not use s.
+//
+//	s.Update(func(old interface{}) interface{} {
+//		count, _ := old.(int)
+//		return count + 1
+//	}, "stats", "requests")
+func (s *SyncHash) Update(
+	fn func(old interface{}) interface{}, path ...string,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.Set(copyValue(fn(copyValue(s.hash.Get(path...)))), path...)
+}
+
+// Atomically sets new value under given path if current value equals to old
+// one. Values are compared like Diff does, and nil old value means there is
+// no value at all. Returns true if value was set.
+func (s *SyncHash) CompareAndSet(old, new interface{}, path ...string) bool {
+	new = copyValue(new)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.hash.Get(path...)
+	if (old == nil) != (current == nil) {
+		return false
+	}
+	if old != nil && !valuesEqual(current, old) {
+		return false
+	}
+
+	s.hash.Set(new, path...)
+	return true
+}
+
+// Replaces the whole content by copy of value, see Hash.SetRoot.
+func (s *SyncHash) SetRoot(value map[string]interface{}) {
+	value = copyValue(value).(map[string]interface{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hash.SetRoot(value)
+}
+
+// Resolves all references in place, see Hash.Resolve.
+func (s *SyncHash) Resolve() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.Resolve()
+}
+
+// Validates whole hash against schema, see Hash.Validate.
+func (s *SyncHash) Validate(schema *Schema) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.Validate(schema)
+}
+
+// Returns read-only deep copy of hash, see Hash.Snapshot.
+func (s *SyncHash) Snapshot() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.Snapshot()
+}
+
+// Sets values from environment variables, see Hash.LoadEnv.
+func (s *SyncHash) LoadEnv(prefix string, opts ...EnvOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.LoadEnv(prefix, opts...)
+}
+
+// Applies overrides in Helm --set syntax, see Hash.ApplySet.
+func (s *SyncHash) ApplySet(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.ApplySet(str)
+}
+
+func (s *SyncHash) ApplySetString(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.ApplySetString(str)
+}
+
+func (s *SyncHash) ApplySetFile(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.ApplySetFile(str)
+}
+
+// Retrieves copy of value by string path, see Hash.GetP.
+func (s *SyncHash) GetP(path string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyValue(s.hash.GetP(path))
+}
+
+func (s *SyncHash) GetMapP(path string) (map[string]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	return s.GetMap(p...)
+}
+
+func (s *SyncHash) GetHashP(path string) (Hash, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return NewHash(), err
+	}
+	return s.GetHash(p...)
+}
+
+func (s *SyncHash) GetStringP(path string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetStringP(path)
+}
+
+func (s *SyncHash) GetBoolP(path string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetBoolP(path)
+}
+
+func (s *SyncHash) GetIntP(path string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetIntP(path)
+}
+
+func (s *SyncHash) GetFloatP(path string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hash.GetFloatP(path)
+}
+
+func (s *SyncHash) GetSliceP(path string) ([]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []interface{}{}, err
+	}
+	return s.GetSlice(p...)
+}
+
+func (s *SyncHash) GetIntSliceP(path string) ([]int64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []int64{}, err
+	}
+	return s.GetIntSlice(p...)
+}
+
+func (s *SyncHash) GetFloatSliceP(path string) ([]float64, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []float64{}, err
+	}
+	return s.GetFloatSlice(p...)
+}
+
+func (s *SyncHash) GetStringSliceP(path string) ([]string, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []string{}, err
+	}
+	return s.GetStringSlice(p...)
+}
+
+func (s *SyncHash) GetMapSliceP(path string) ([]map[string]interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return []map[string]interface{}{}, err
+	}
+	return s.GetMapSlice(p...)
+}
+
+func (s *SyncHash) DecodeP(v interface{}, path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return s.Decode(v, p...)
+}
+
+// Sets copy of value by string path, see Hash.SetP.
+func (s *SyncHash) SetP(value interface{}, path string) error {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetP(value, path)
+}
+
+func (s *SyncHash) SetStrictP(value interface{}, path string) error {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetStrictP(value, path)
+}
+
+func (s *SyncHash) SetIfAbsentP(value interface{}, path string) (bool, error) {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.SetIfAbsentP(value, path)
+}
+
+func (s *SyncHash) SetDefaultP(
+	value interface{}, path string,
+) (interface{}, error) {
+	value = copyValue(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, err := s.hash.SetDefaultP(value, path)
+	return copyValue(result), err
+}
+
+func (s *SyncHash) DeleteP(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.DeleteP(path)
+}
+
+func (s *SyncHash) AppendSliceP(val interface{}, path string) error {
+	val = copyValue(val)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendSliceP(val, path)
+}
+
+func (s *SyncHash) AppendIntSliceP(val int64, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendIntSliceP(val, path)
+}
+
+func (s *SyncHash) AppendFloatSliceP(val float64, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendFloatSliceP(val, path)
+}
+
+func (s *SyncHash) AppendStringSliceP(val string, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendStringSliceP(val, path)
+}
+
+func (s *SyncHash) AppendMapSliceP(
+	val map[string]interface{}, path string,
+) error {
+	val = copyValue(val).(map[string]interface{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hash.AppendMapSliceP(val, path)
+}
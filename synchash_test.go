@@ -0,0 +1,274 @@
+package zhash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSyncHashCopies(t *testing.T) {
+	source := HashFromMap(map[string]interface{}{
+		"db":   map[string]interface{}{"host": "localhost"},
+		"tags": []interface{}{"a"},
+	})
+	s := NewSyncHash(source)
+
+	source.Set("changed", "db", "host")
+	if host, _ := s.GetString("db", "host"); host != "localhost" {
+		t.Errorf("NewSyncHash does not copy hash, host=%q", host)
+	}
+
+	m, _ := s.GetMap("db")
+	m["host"] = "changed"
+	tags, _ := s.GetSlice("tags")
+	tags[0] = "changed"
+	sub, _ := s.GetHash("db")
+	sub.Set("changed", "host")
+
+	expected := map[string]interface{}{
+		"db":   map[string]interface{}{"host": "localhost"},
+		"tags": []interface{}{"a"},
+	}
+	if root := s.Hash().GetRoot(); !reflect.DeepEqual(root, expected) {
+		t.Errorf("SyncHash changed via returned values: %#v", root)
+	}
+}
+
+func TestSyncHashUpdate(t *testing.T) {
+	s := NewSyncHash(NewHash())
+
+	inc := func(old interface{}) interface{} {
+		count, _ := old.(int)
+		return count + 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update(inc, "counter")
+		}()
+	}
+	wg.Wait()
+
+	if count := s.Get("counter"); count != 50 {
+		t.Errorf("counter=%v; want 50", count)
+	}
+}
+
+func TestSyncHashCompareAndSet(t *testing.T) {
+	s := NewSyncHash(NewHash())
+
+	tests := []struct {
+		old, new interface{}
+		set      bool
+	}{
+		{"a", "b", false},
+		{nil, "a", true},
+		{nil, "b", false},
+		{"b", "c", false},
+		{"a", []interface{}{1}, true},
+		{[]interface{}{1.0}, 2, true},
+		{int64(2), nil, true},
+	}
+
+	for i, test := range tests {
+		set := s.CompareAndSet(test.old, test.new, "key")
+		if set != test.set {
+			t.Errorf("#%d: CompareAndSet(%v, %v)=%v; want %v",
+				i, test.old, test.new, set, test.set)
+		}
+	}
+}
+
+func TestSyncHashReadHash(t *testing.T) {
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	s := NewSyncHash(h)
+	s.Set("value", "old")
+
+	var changes []interface{}
+	s.OnChange("*", func(old, new interface{}) {
+		changes = append(changes, old, new)
+	})
+
+	if err := s.ReadHash(bytes.NewBufferString(`{"new": 1`)); err == nil {
+		t.Error("ReadHash of corrupted json does not fail")
+	}
+	if s.Get("old") != "value" {
+		t.Error("failed ReadHash changed hash")
+	}
+
+	if err := s.ReadHash(bytes.NewBufferString(`{"new": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Get("old") != nil || s.Get("new") != 1.0 {
+		t.Errorf("ReadHash()=%s", s)
+	}
+
+	expected := []interface{}{nil, 1.0, "value", nil}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("OnChange calls=%v; want %v", changes, expected)
+	}
+}
+
+func TestSyncHashConcurrent(t *testing.T) {
+	s := NewSyncHash(NewHash())
+	s.Set([]interface{}{}, "list")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprint(i)
+				s.Set(j, "values", key)
+				s.AppendSlice(j, "list")
+				s.AppendIntSlice(int64(j), "ints", key)
+				if j%10 == 0 {
+					s.Delete("values", key)
+				}
+				s.Merge(HashFromMap(map[string]interface{}{
+					"merged": map[string]interface{}{key: j},
+				}))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Get("values")
+				s.GetSlice("list")
+				s.GetIntSlice("ints", "0")
+				s.GetMap("merged")
+				s.Keys()
+				_ = s.String()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if list, _ := s.GetSlice("list"); len(list) != 800 {
+		t.Errorf("len(list)=%d; want 800", len(list))
+	}
+}
+
+func TestSyncHashDecodeCopies(t *testing.T) {
+	s := NewSyncHash(HashFromMap(map[string]interface{}{
+		"extra": map[string]interface{}{"a": 1},
+		"list":  []interface{}{1, 2},
+	}))
+
+	var cfg struct {
+		Extra interface{}   `zhash:"extra"`
+		List  []interface{} `zhash:"list"`
+	}
+	if err := s.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for j := 0; j < 100; j++ {
+			s.Set(j, "extra", "b")
+		}
+	}()
+	for j := 0; j < 100; j++ {
+		cfg.Extra.(map[string]interface{})["a"] = j
+		cfg.List[0] = j
+	}
+	<-done
+
+	if first, _ := s.GetInt("list", "0"); first != 1 {
+		t.Errorf("list[0]=%d; want 1", first)
+	}
+	if a, _ := s.GetInt("extra", "a"); a != 1 {
+		t.Errorf("extra.a=%d; want 1", a)
+	}
+}
+
+func TestSyncHashMethods(t *testing.T) {
+	skipped := map[string]bool{
+		"GetRoot": true, "Normalize": true, "SetNormalize": true,
+	}
+
+	syncType := reflect.TypeOf(&SyncHash{})
+	hashType := reflect.TypeOf(&Hash{})
+	for i := 0; i < hashType.NumMethod(); i++ {
+		name := hashType.Method(i).Name
+		if _, ok := syncType.MethodByName(name); !ok && !skipped[name] {
+			t.Errorf("SyncHash has no %s method", name)
+		}
+	}
+}
+
+func TestSyncHashPathAccessors(t *testing.T) {
+	s := NewSyncHash(NewHash())
+
+	if err := s.SetP(8080, "servers[1].port"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ApplySet("servers[0].port=80,debug=true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadEnv("APP", EnvFrom([]string{"APP_NAME=api"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if port, err := s.GetIntP("servers[1].port"); port != 8080 || err != nil {
+		t.Errorf("GetIntP(servers[1].port)=%d, %v", port, err)
+	}
+	servers, _ := s.GetMapSliceP("servers")
+	servers[0]["port"] = 0
+	if port, _ := s.GetIntP("servers[0].port"); port != 80 {
+		t.Errorf("GetMapSliceP result is not a copy, port=%d", port)
+	}
+
+	if err := s.DeleteP("servers[0]"); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"servers": []interface{}{map[string]interface{}{"port": 8080}},
+		"debug":   true,
+		"name":    "api",
+	}
+	if root := s.Hash().GetRoot(); !reflect.DeepEqual(root, expected) {
+		t.Errorf("SyncHash=%#v; want %#v", root, expected)
+	}
+
+	root := map[string]interface{}{"a": 1}
+	s.SetRoot(root)
+	root["a"] = 2
+	if a := s.Get("a"); a != 1 {
+		t.Errorf("SetRoot keeps reference to value, a=%v", a)
+	}
+}
+
+func TestSyncHashReadFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(source, []byte("port: 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSyncHash(NewHash())
+	if err := s.ReadFile(source); err != nil {
+		t.Fatal(err)
+	}
+	if port, _ := s.GetInt("port"); port != 80 {
+		t.Errorf("port=%d; want 80", port)
+	}
+
+	// format of read file is kept
+	var buf bytes.Buffer
+	if err := s.WriteHash(&buf); err != nil || buf.String() != "port: 80\n" {
+		t.Errorf("WriteHash()=%q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,203 @@
+package zhash
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// WatchOption changes behaviour of WatchFile.
+type WatchOption func(*Watcher)
+
+// Sets how often watched file is checked, one second by default. Interval
+// must be positive, WatchFile fails otherwise.
+func WatchInterval(interval time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// Watcher reloads hash from file when the file changes. File is polled:
+// its modification time and size are checked, and content is reloaded only
+// if its sha256 sum differs from the loaded one. As file is found by path on
+// every check, atomic rename writes of editors and symlink swaps of
+// Kubernetes config maps are handled too.
+//
+// Loaded hash is kept as Snapshot, so it can be read from any goroutine.
+// If file can not be read or parsed, the previous value is kept and error is
+// reported to OnError callbacks.
+//
+// OnChange subscribers of the hash given to WatchFile are notified about
+// changes on every reload, as if it was replaced by SetRoot, though the hash
+// itself is never changed.
+type Watcher struct {
+	path      string
+	interval  time.Duration
+	holder    *Holder
+	listeners *listeners
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	sum      [sha256.Size]byte
+	onReload []func(old, new Hash)
+	onError  []func(err error)
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Loads file into hash having the same settings as h and starts watching it.
+// Unmarshaller of h is used for parsing, so it must be set.
+//
+//	h := zhash.NewHash()
+//	h.SetUnmarshallerFunc(json.Unmarshal)
+//	w, err := zhash.WatchFile("config.json", h)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//
+//	w.OnReload(func(old, new zhash.Hash) {
+//		log.Print(zhash.Diff(old, new))
+//	})
+//	port, err := w.Load().GetInt("server", "port")
+func WatchFile(path string, h Hash, opts ...WatchOption) (*Watcher, error) {
+	w := &Watcher{
+		path:      path,
+		interval:  time.Second,
+		holder:    NewHolder(h.emptyCopy()),
+		listeners: h.listeners,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.interval <= 0 {
+		return nil, fmt.Errorf("invalid watch interval %s", w.interval)
+	}
+
+	if _, err := w.check(true); err != nil {
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Returns snapshot of the last successfully loaded hash.
+func (w *Watcher) Load() *Snapshot {
+	return w.holder.Load()
+}
+
+// Returns holder keeping the last successfully loaded hash.
+func (w *Watcher) Holder() *Holder {
+	return w.holder
+}
+
+// Adds callback called after every reload with copies of previous and new
+// hash. Callbacks are called from watcher goroutine one by one, and may call
+// only Load and Holder methods of the watcher. The same goes for OnChange
+// subscribers of the watched hash.
+func (w *Watcher) OnReload(fn func(old, new Hash)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onReload = append(w.onReload, fn)
+}
+
+// Adds callback called when file can not be read or parsed.
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Checks file immediately and reloads it if it has changed. Returns true if
+// hash was reloaded. Error is returned to caller, and not to OnError
+// callbacks.
+func (w *Watcher) Check() (bool, error) {
+	return w.check(false)
+}
+
+// Stops watching the file.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+	return nil
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if _, err := w.check(false); err != nil {
+				w.mu.Lock()
+				callbacks := w.onError
+				w.mu.Unlock()
+				for _, fn := range callbacks {
+					fn(err)
+				}
+			}
+		}
+	}
+}
+
+func (w *Watcher) check(initial bool) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, err
+	}
+	if !initial && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, err
+	}
+
+	// broken content is remembered too, so it is reported only once
+	sum := sha256.Sum256(content)
+	changed := initial || sum != w.sum
+	w.modTime, w.size, w.sum = info.ModTime(), info.Size(), sum
+	if !changed {
+		return false, nil
+	}
+
+	old := w.holder.Load()
+	fresh := old.hash.emptyCopy()
+	if err := fresh.ReadHash(bytes.NewReader(content)); err != nil {
+		return false, err
+	}
+
+	current := fresh.Snapshot()
+	w.holder.Store(current)
+
+	if !initial {
+		source := Hash{data: old.hash.data, listeners: w.listeners}
+		source.SetRoot(current.hash.data)
+
+		for _, fn := range w.onReload {
+			fn(old.Hash(), current.Hash())
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,157 @@
+package zhash
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeWatched(t *testing.T, path string, content string, modTime time.Time) {
+	// write via rename, like editors and kubernetes do
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	start := time.Now().Add(-time.Hour)
+	writeWatched(t, path, `{"port": 80}`, start)
+
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	w, err := WatchFile(path, h, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if port := w.Load().Get("port"); port != 80.0 {
+		t.Fatalf("port=%v; want 80", port)
+	}
+
+	var reloads [][2]interface{}
+	w.OnReload(func(old, new Hash) {
+		reloads = append(reloads, [2]interface{}{old.Get("port"), new.Get("port")})
+	})
+
+	var changes [][2]interface{}
+	h.OnChange("port", func(old, new interface{}) {
+		changes = append(changes, [2]interface{}{old, new})
+	})
+
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		t.Errorf("Check() of unchanged file=%v, %v", reloaded, err)
+	}
+
+	writeWatched(t, path, `{"port": 80}`, start.Add(time.Minute))
+	if reloaded, err := w.Check(); reloaded || err != nil {
+		t.Errorf("Check() of touched file=%v, %v", reloaded, err)
+	}
+
+	writeWatched(t, path, `{"port": 81}`, start.Add(2*time.Minute))
+	if reloaded, err := w.Check(); !reloaded || err != nil {
+		t.Errorf("Check() of changed file=%v, %v", reloaded, err)
+	}
+
+	writeWatched(t, path, `{"port": `, start.Add(3*time.Minute))
+	if reloaded, err := w.Check(); reloaded || err == nil {
+		t.Errorf("Check() of corrupted file=%v, %v", reloaded, err)
+	}
+	if port := w.Load().Get("port"); port != 81.0 {
+		t.Errorf("port after failed reload=%v; want 81", port)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Check(); err == nil {
+		t.Error("Check() of removed file does not fail")
+	}
+
+	writeWatched(t, path, `{"port": 82}`, start.Add(4*time.Minute))
+	if reloaded, err := w.Check(); !reloaded || err != nil {
+		t.Errorf("Check() of recreated file=%v, %v", reloaded, err)
+	}
+
+	expected := [][2]interface{}{{80.0, 81.0}, {81.0, 82.0}}
+	if len(reloads) != len(expected) ||
+		reloads[0] != expected[0] || reloads[1] != expected[1] {
+		t.Errorf("reloads=%v; want %v", reloads, expected)
+	}
+	if len(changes) != len(expected) ||
+		changes[0] != expected[0] || changes[1] != expected[1] {
+		t.Errorf("OnChange calls=%v; want %v", changes, expected)
+	}
+	if h.Get("port") != nil {
+		t.Errorf("watched hash was changed: %s", h)
+	}
+}
+
+func TestWatcherPolling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	start := time.Now().Add(-time.Hour)
+	writeWatched(t, path, `{"port": 80}`, start)
+
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	w, err := WatchFile(path, h, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	reloaded := make(chan interface{}, 1)
+	failed := make(chan error, 1)
+	w.OnReload(func(old, new Hash) {
+		reloaded <- new.Get("port")
+	})
+	w.OnError(func(err error) {
+		failed <- err
+	})
+
+	writeWatched(t, path, `{"port": `, start.Add(time.Minute))
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("corrupted file is not reported")
+	}
+
+	writeWatched(t, path, `{"port": 81}`, start.Add(2*time.Minute))
+	select {
+	case port := <-reloaded:
+		if port != 81.0 {
+			t.Errorf("reloaded port=%v; want 81", port)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("changed file is not reloaded")
+	}
+}
+
+func TestWatchFileFails(t *testing.T) {
+	h := NewHash()
+	h.SetUnmarshallerFunc(json.Unmarshal)
+	if _, err := WatchFile(filepath.Join(t.TempDir(), "missing"), h); err == nil {
+		t.Error("WatchFile of missing file does not fail")
+	}
+	if _, err := WatchFile("test.json", NewHash()); err == nil {
+		t.Error("WatchFile without unmarshaller does not fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeWatched(t, path, `{"port": 80}`, time.Now())
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := WatchFile(path, h, WatchInterval(interval)); err == nil {
+			t.Errorf("WatchFile with interval %s does not fail", interval)
+		}
+	}
+}
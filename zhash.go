@@ -2,28 +2,35 @@ package zhash // import "github.com/zazab/zhash"
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
 type Hash struct {
-	data      map[string]interface{}
-	marshal   Marshaller
-	unmarshal Unmarshaller
+	data        map[string]interface{}
+	marshal     Marshaller
+	unmarshal   Unmarshaller
+	coercion    Coercion
+	interpolate bool
+	includeKey  string
+	normalize   bool
+	listeners   *listeners
 }
 
 func NewHash() Hash {
-	return Hash{map[string]interface{}{}, nil, nil}
+	return Hash{data: map[string]interface{}{}, listeners: newListeners()}
 }
 
 func NewHashPtr() *Hash {
-	return &Hash{map[string]interface{}{}, nil, nil}
+	return &Hash{data: map[string]interface{}{}, listeners: newListeners()}
 }
 
 // Loads existing map[string]interface{} to Hash. Marshaller and Unmarshallers
 // are optional, if you don't need it pass nil to them. You can set (or change)
 // them later using Hash.SetMarshaller and Hash.SetUnmarshaller.
 func HashFromMap(ma map[string]interface{}) Hash {
-	return Hash{ma, nil, nil}
+	return Hash{data: ma, listeners: newListeners()}
 }
 
 type notFoundError struct {
@@ -41,39 +48,224 @@ func IsNotFound(err error) bool {
 	return ok
 }
 
+// Sets value under given path. Path elements pointing into slices are
+// treated as indexes: existing element is replaced, and index beyond the end
+// grows the slice, filling the gap with nils. Slices are not grown beyond
+// index 65536, nothing is changed then. Missing parents are created as maps.
 func (h Hash) Set(value interface{}, path ...string) {
-	key := ""
-	ptr := h.data
+	if len(path) == 0 {
+		path = []string{""}
+	}
+
+	h.setSegments(value, keySegments(path), false)
+}
+
+// Sets value like Set does, but refuses to replace anything except the target
+// element itself. If any element in the way is neither map nor slice, or
+// index points beyond the end of slice, nothing is changed and
+// *ConflictError or not found error is returned.
+func (h Hash) SetStrict(value interface{}, path ...string) error {
+	if len(path) == 0 {
+		path = []string{""}
+	}
+
+	return h.setSegments(value, keySegments(path), true)
+}
+
+// Sets value only if nothing is set under the path yet. Returns true if value
+// was set. Parents are checked in the same way as SetStrict does.
+func (h Hash) SetIfAbsent(value interface{}, path ...string) (bool, error) {
+	if h.getRaw(path) != nil {
+		return false, nil
+	}
+
+	err := h.SetStrict(value, path...)
+	return err == nil, err
+}
+
+// Returns value found under the path, or sets value there and returns it if
+// nothing was set. Parents are checked in the same way as SetStrict does.
+func (h Hash) SetDefault(value interface{}, path ...string) (interface{}, error) {
+	if current := h.getRaw(path); current != nil {
+		return current, nil
+	}
+
+	err := h.SetStrict(value, path...)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (h Hash) setSegments(value interface{}, path []segment, strict bool) error {
+	return h.notifyChange(h.changePrefix(segmentKeys(path)), func() error {
+		_, err := setter{path, value, strict}.set(h.data, 0)
+		return err
+	})
+}
+
+func keySegments(path []string) []segment {
+	segments := make([]segment, len(path))
 	for i, p := range path {
-		if i < len(path)-1 { // middle element
-			switch node := ptr[p].(type) {
-			case map[string]interface{}:
-				ptr = node
-			case map[interface{}]interface{}:
-				// golang yaml implementations parses data into
-				// map[interface{}]interface{}. zhash works with
-				// map[string]interface{}. So we need to  convert
-				// map[interface{}]interface{} to map[string]interface{}
-				// or it would be overwritten by empty map[string]interface{}
-				// on set attempt
-				ptr[p] = convertToMapString(node)
-				ptr = ptr[p].(map[string]interface{})
-			default:
-				ptr[p] = map[string]interface{}{}
-				ptr = ptr[p].(map[string]interface{})
-			}
+		segments[i] = segment{key: p}
+	}
+	return segments
+}
+
+// ConflictError is returned by SetStrict and friends when element in the way
+// to target path is neither map nor slice.
+type ConflictError struct {
+	// Path of the conflicting element
+	Path []string
+	// Current value of the conflicting element
+	Value interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"cannot set value under %s, it is %T, expected map or slice",
+		strings.Join(e.Path, "."), e.Value,
+	)
+}
+
+// Check if given err is *ConflictError returned by SetStrict.
+func IsConflict(err error) bool {
+	_, ok := err.(*ConflictError)
+	return ok
+}
+
+// maxIndex is the largest index slices are grown to by Set and friends, like
+// Helm does, so paths coming from users, like --set overrides, can not
+// allocate huge slices.
+const maxIndex = 65536
+
+type indexLimitError struct {
+	path  []string
+	index int
+}
+
+func (e indexLimitError) Error() string {
+	return fmt.Sprintf(
+		"cannot set value under %s, index %d exceeds limit %d",
+		strings.Join(e.path, "."), e.index, maxIndex,
+	)
+}
+
+type setter struct {
+	path   []segment
+	value  interface{}
+	strict bool
+}
+
+// set sets value under path[pos:] relative to node and returns node which
+// should be stored by the caller in place of the old one. Maps are changed in
+// place, slices are returned grown or converted if needed.
+func (s setter) set(node interface{}, pos int) (interface{}, error) {
+	if pos == len(s.path) {
+		return s.value, nil
+	}
+
+	key := s.path[pos]
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		child, err := s.set(typed[key.key], pos+1)
+		if err != nil {
+			return nil, err
+		}
+		typed[key.key] = child
+		return typed, nil
+	case map[interface{}]interface{}:
+		// golang yaml implementations parses data into
+		// map[interface{}]interface{}. zhash works with
+		// map[string]interface{}. So we need to  convert
+		// map[interface{}]interface{} to map[string]interface{}
+		// or it would be overwritten by empty map[string]interface{}
+		// on set attempt
+		converted := convertToMapString(typed)
+		child, err := s.set(converted[key.key], pos+1)
+		if err != nil {
+			return nil, err
+		}
+		converted[key.key] = child
+		return converted, nil
+	}
+
+	if slice, ok := asSlice(node); ok {
+		if index, ok := sliceIndex(key.key); ok {
+			return s.setIndex(slice, index, pos)
+		}
+	}
+
+	if node != nil && s.strict {
+		return nil, &ConflictError{
+			Path:  segmentKeys(s.path[:pos]),
+			Value: node,
 		}
-		key = p
 	}
 
-	ptr[key] = value
+	if key.index {
+		index, _ := sliceIndex(key.key)
+		return s.setIndex(reflect.ValueOf([]interface{}{}), index, pos)
+	}
+
+	return s.set(map[string]interface{}{}, pos)
+}
+
+func (s setter) setIndex(
+	slice reflect.Value, index int, pos int,
+) (interface{}, error) {
+	if s.strict && index > slice.Len() {
+		return nil, notFoundError{segmentKeys(s.path[:pos+1])}
+	}
+	if index >= slice.Len() && index > maxIndex {
+		return nil, indexLimitError{segmentKeys(s.path[:pos+1]), index}
+	}
+
+	var elem interface{}
+	if index < slice.Len() {
+		elem = slice.Index(index).Interface()
+	}
+
+	elem, err := s.set(elem, pos+1)
+	if err != nil {
+		return nil, err
+	}
+
+	elemValue := reflect.ValueOf(elem)
+	elemType := slice.Type().Elem()
+	switch {
+	case elem == nil && canBeNil(elemType.Kind()):
+		elemValue = reflect.Zero(elemType)
+	case elem == nil || !elemValue.Type().AssignableTo(elemType):
+		slice = reflect.ValueOf(toInterfaceSlice(slice))
+		elemValue = reflect.ValueOf(&elem).Elem()
+	}
+
+	for slice.Len() <= index {
+		slice = reflect.Append(slice, reflect.Zero(slice.Type().Elem()))
+	}
+
+	slice.Index(index).Set(elemValue)
+	return slice.Interface(), nil
 }
 
 func (h *Hash) SetRoot(value map[string]interface{}) {
-	h.data = value
+	h.notifyChange(nil, func() error {
+		h.data = value
+		return nil
+	})
 }
 
+// Deletes value under given path. If parent is a slice, element is removed
+// from it and following elements are shifted.
 func (h Hash) Delete(path ...string) error {
+	return h.notifyChange(h.changePrefix(path), func() error {
+		return h.delete(path)
+	})
+}
+
+func (h Hash) delete(path []string) error {
 	l := len(path)
 	if l == 1 {
 		delete(h.data, path[0])
@@ -82,7 +274,7 @@ func (h Hash) Delete(path ...string) error {
 
 	elemPath := path[l-1]
 	parentPath := path[:l-1]
-	parent := h.Get(parentPath...)
+	parent := normalizedLookup(h.data, parentPath)
 
 	if parent == nil {
 		return notFoundError{path}
@@ -92,41 +284,148 @@ func (h Hash) Delete(path ...string) error {
 	case map[string]interface{}:
 		delete(val, elemPath)
 		return nil
-	default:
-		return fmt.Errorf(
-			"cannot delete key %s from %T, "+
-				"expected map[string]interface{}",
-			strings.Join(path, "."), parent,
+	}
+
+	if slice, ok := asSlice(parent); ok {
+		index, ok := sliceIndex(elemPath)
+		if !ok {
+			return fmt.Errorf(
+				"cannot delete key %s from %T, expected index",
+				strings.Join(path, "."), parent,
+			)
+		}
+		if index >= slice.Len() {
+			return notFoundError{path}
+		}
+
+		removed := reflect.MakeSlice(slice.Type(), 0, slice.Len()-1)
+		removed = reflect.AppendSlice(removed, slice.Slice(0, index))
+		removed = reflect.AppendSlice(
+			removed, slice.Slice(index+1, slice.Len()),
 		)
+
+		h.Set(removed.Interface(), parentPath...)
+		return nil
 	}
+
+	return fmt.Errorf(
+		"cannot delete key %s from %T, "+
+			"expected map[string]interface{}",
+		strings.Join(path, "."), parent,
+	)
 }
 
-// Retrieves value from hash returns nil if nothing found
+// Retrieves value from hash returns nil if nothing found. Path elements
+// pointing into slices are treated as indexes. If interpolation is enabled,
+// references are resolved, and nil is returned if it fails.
 func (h Hash) Get(path ...string) interface{} {
-	ptr := h.data
-	for i, p := range path {
-		if i == len(path)-1 {
-			if node, ok := ptr[p].(map[interface{}]interface{}); ok {
-				return convertToMapString(node)
+	value, err := h.getValue(path)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+func (h Hash) getRaw(path []string) interface{} {
+	if len(path) == 0 {
+		return nil
+	}
+
+	node, _ := lookup(h.data, path)
+	if typed, ok := node.(map[interface{}]interface{}); ok {
+		return convertToMapString(typed)
+	}
+
+	return node
+}
+
+// lookup walks from node by path and returns found value. Unlike Get it
+// tells nil values from missing ones.
+func lookup(node interface{}, path []string) (interface{}, bool) {
+	for _, p := range path {
+		var ok bool
+		node, ok = child(node, p)
+		if !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func child(node interface{}, key string) (interface{}, bool) {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		val, ok := typed[key]
+		return val, ok
+	case map[interface{}]interface{}:
+		if val, ok := typed[key]; ok {
+			return val, true
+		}
+		// the same key convertToMapString would give
+		for k, val := range typed {
+			if _, ok := k.(string); !ok && fmt.Sprint(k) == key {
+				return val, true
 			}
-			return ptr[p]
 		}
+		return nil, false
+	}
 
-		switch node := ptr[p].(type) {
-		case map[string]interface{}:
-			ptr = node
-		case map[interface{}]interface{}:
-			ptr = convertToMapString(node)
-		default:
-			return nil
+	if slice, ok := asSlice(node); ok {
+		index, ok := sliceIndex(key)
+		if !ok || index >= slice.Len() {
+			return nil, false
 		}
+		return slice.Index(index).Interface(), true
+	}
+
+	return nil, false
+}
+
+func asSlice(node interface{}) (reflect.Value, bool) {
+	if node == nil {
+		return reflect.Value{}, false
+	}
+
+	value := reflect.ValueOf(node)
+	return value, value.Kind() == reflect.Slice
+}
+
+func sliceIndex(key string) (int, bool) {
+	if !isIndex(key) {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(key)
+	return index, err == nil
+}
+
+func toInterfaceSlice(slice reflect.Value) []interface{} {
+	result := make([]interface{}, slice.Len())
+	for i := range result {
+		result[i] = slice.Index(i).Interface()
 	}
+	return result
+}
 
-	return nil
+func canBeNil(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice,
+		reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
 }
 
+// convertToMapString converts yaml map to map[string]interface{}. Non string
+// keys (yaml gives ints, floats and bools) are formatted by fmt.Sprint, and
+// string key wins if both give the same string.
 func convertToMapString(node map[interface{}]interface{}) map[string]interface{} {
-	convertedNode := make(map[string]interface{})
+	convertedNode := make(map[string]interface{}, len(node))
+	for key, val := range node {
+		if _, ok := key.(string); !ok {
+			convertedNode[fmt.Sprint(key)] = val
+		}
+	}
 	for key, val := range node {
 		if keystr, ok := key.(string); ok {
 			convertedNode[keystr] = val
@@ -144,36 +443,25 @@ func (h Hash) GetRoot() map[string]interface{} {
 // target value, or value doesn't found. If not found, returns empty
 // Hash, not nil
 func (h Hash) GetMap(path ...string) (map[string]interface{}, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return map[string]interface{}{}, notFoundError{path}
-	}
-	switch val := m.(type) {
-	case map[string]interface{}:
-		return val, nil
-	default:
-		return map[string]interface{}{}, fmt.Errorf(
-			"cannot convert %s to map", strings.Join(path, "."),
-		)
+	m, err := get(h, assertConverter[map[string]interface{}], path)
+	if err != nil {
+		return map[string]interface{}{}, err
 	}
+	return m, nil
 }
 
 // Retrieves map[string]interface{} and converts it to Hash. Returns error if
 // can not convert target value, or value doesn'n found. If not found returns
 // emty map[string]interface{} not nil
 func (h Hash) GetHash(path ...string) (Hash, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return NewHash(), notFoundError{path}
-	}
-	switch val := m.(type) {
-	case map[string]interface{}:
-		return HashFromMap(val), nil
-	default:
-		return NewHash(), fmt.Errorf(
-			"cannot convert %s to map", strings.Join(path, "."),
-		)
+	m, err := get(h, assertConverter[map[string]interface{}], path)
+	if err != nil {
+		return NewHash(), err
 	}
+
+	hash := HashFromMap(m)
+	hash.coercion = h.coercion
+	return hash, nil
 }
 
 // Returns root keys of Hash
@@ -194,67 +482,17 @@ func (h Hash) Len() int {
 }
 
 func (h Hash) GetString(path ...string) (string, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return "", notFoundError{path}
-	}
-	switch val := m.(type) {
-	case string:
-		return val, nil
-	default:
-		return "", fmt.Errorf(
-			"cannot convert %s to string", strings.Join(path, "."),
-		)
-	}
+	return Get[string](h, path...)
 }
 
 func (h Hash) GetBool(path ...string) (bool, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return false, notFoundError{path}
-	}
-	switch val := m.(type) {
-	case bool:
-		return val, nil
-	default:
-		return false, fmt.Errorf(
-			"cannot convert %s to bool", strings.Join(path, "."),
-		)
-	}
+	return Get[bool](h, path...)
 }
 
 func (h Hash) GetInt(path ...string) (int64, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return 0, notFoundError{path}
-	}
-	switch val := m.(type) {
-	case int:
-		return int64(val), nil
-	case int64:
-		return val, nil
-	default:
-		return 0, fmt.Errorf(
-			"cannot convert %s to int", strings.Join(path, "."),
-		)
-	}
+	return Get[int64](h, path...)
 }
 
 func (h Hash) GetFloat(path ...string) (float64, error) {
-	m := h.Get(path...)
-	if m == nil {
-		return 0, notFoundError{path}
-	}
-	switch val := m.(type) {
-	case float64:
-		return val, nil
-	case int:
-		return float64(val), nil
-	case int64:
-		return float64(val), nil
-	default:
-		return 0, fmt.Errorf(
-			"cannot convert %s to float", strings.Join(path, "."),
-		)
-	}
+	return Get[float64](h, path...)
 }
@@ -284,3 +284,191 @@ func TestGetBool(t *testing.T) {
 		checkGet(i, test, b, err, "GetBool", t)
 	}
 }
+
+func TestGetSliceIndex(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"intSlice": []int{10, 12, 14},
+		"deploy": map[interface{}]interface{}{
+			"chmod": []interface{}{
+				map[interface{}]interface{}{"mode": "0644"},
+				map[interface{}]interface{}{"mode": "0755"},
+			},
+		},
+		"maps": []map[string]interface{}{{"name": "first"}},
+	})
+
+	tests := []getTest{
+		{[]string{"intSlice", "1"}, 12, false},
+		{[]string{"intSlice", "3"}, nil, false},
+		{[]string{"intSlice", "x"}, nil, false},
+		{[]string{"deploy", "chmod", "1", "mode"}, "0755", false},
+		{[]string{"maps", "0", "name"}, "first", false},
+	}
+
+	for i, test := range tests {
+		value := hash.Get(test.path...)
+		if !reflect.DeepEqual(value, test.value) {
+			t.Errorf("#%d: Get(%s)=%#v; want %#v", i, test.path, value, test.value)
+		}
+	}
+
+	_, err := hash.GetInt("intSlice", "3")
+	if !IsNotFound(err) {
+		t.Errorf("GetInt with out of range index returned %v", err)
+	}
+}
+
+func TestSetSliceIndex(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"intSlice":  []int{10, 12, 14},
+		"intISlice": []interface{}{30, 32},
+		"list": []interface{}{
+			map[string]interface{}{"name": "a"},
+		},
+	})
+
+	hash.Set(11, "intSlice", "0")
+	hash.Set("s", "intSlice", "1")
+	hash.Set(34, "intISlice", "3")
+	hash.Set("b", "list", "0", "name")
+	hash.Set("c", "list", "1", "name")
+
+	expected := map[string]interface{}{
+		"intSlice":  []interface{}{11, "s", 14},
+		"intISlice": []interface{}{30, 32, nil, 34},
+		"list": []interface{}{
+			map[string]interface{}{"name": "b"},
+			map[string]interface{}{"name": "c"},
+		},
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("GetRoot()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+
+	typed := []int{1, 2}
+	hash.Set(typed, "typed")
+	hash.Set(3, "typed", "1")
+	if typed[1] != 3 {
+		t.Errorf("Set into []int doesn't replace element in place: %v", typed)
+	}
+	hash.Set(4, "typed", "2")
+	if v := hash.Get("typed"); !reflect.DeepEqual(v, []int{1, 3, 4}) {
+		t.Errorf("Set past the end of []int=%#v; want []int{1, 3, 4}", v)
+	}
+}
+
+func TestDeleteSliceIndex(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"intSlice": []int{10, 12, 14},
+		"nested": map[string]interface{}{
+			"list": []interface{}{"a", "b", "c"},
+		},
+	})
+
+	if err := hash.Delete("intSlice", "1"); err != nil {
+		t.Errorf("Delete(intSlice, 1) caused error: %v", err)
+	}
+	if err := hash.Delete("nested", "list", "0"); err != nil {
+		t.Errorf("Delete(nested, list, 0) caused error: %v", err)
+	}
+	if err := hash.Delete("nested", "list", "2"); !IsNotFound(err) {
+		t.Errorf("Delete with out of range index returned %v", err)
+	}
+	if err := hash.Delete("nested", "list", "x"); err == nil || IsNotFound(err) {
+		t.Errorf("Delete with non-index key from slice returned %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"intSlice": []int{10, 14},
+		"nested": map[string]interface{}{
+			"list": []interface{}{"b", "c"},
+		},
+	}
+
+	if !reflect.DeepEqual(hash.GetRoot(), expected) {
+		t.Errorf("GetRoot()=%#v; want %#v", hash.GetRoot(), expected)
+	}
+}
+
+func TestSetStrict(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"string": "some text",
+		"list":   []interface{}{"a"},
+		"map":    map[string]interface{}{"val1": 10},
+	})
+
+	err := hash.SetStrict(10, "string", "nested")
+	conflict, ok := err.(*ConflictError)
+	if !ok || !IsConflict(err) {
+		t.Fatalf("SetStrict over string returned %v; want *ConflictError", err)
+	}
+	if !reflect.DeepEqual(conflict.Path, []string{"string"}) ||
+		conflict.Value != "some text" {
+		t.Errorf("ConflictError=%#v", conflict)
+	}
+	if hash.Get("string") != "some text" {
+		t.Errorf("SetStrict overwritten conflicting value")
+	}
+
+	err = hash.SetStrict(10, "map", "new", "deep", "list", "key")
+	if err != nil {
+		t.Errorf("SetStrict creating parents caused error: %v", err)
+	}
+
+	err = hash.SetStrict(1, "list", "key")
+	if !IsConflict(err) {
+		t.Errorf("SetStrict with key into slice returned %v", err)
+	}
+	if err := hash.SetStrict("b", "list", "1"); err != nil {
+		t.Errorf("SetStrict appending to slice caused error: %v", err)
+	}
+	if err := hash.SetStrict("d", "list", "3"); !IsNotFound(err) {
+		t.Errorf("SetStrict past the end of slice returned %v", err)
+	}
+	if v := hash.Get("list"); !reflect.DeepEqual(v, []interface{}{"a", "b"}) {
+		t.Errorf("Get(list)=%#v", v)
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"int": 10,
+	})
+
+	set, err := hash.SetIfAbsent(11, "int")
+	if set || err != nil || hash.Get("int") != 10 {
+		t.Errorf("SetIfAbsent over existing value returned %v, %v", set, err)
+	}
+
+	set, err = hash.SetIfAbsent(12, "map", "int")
+	if !set || err != nil || hash.Get("map", "int") != 12 {
+		t.Errorf("SetIfAbsent into empty place returned %v, %v", set, err)
+	}
+
+	set, err = hash.SetIfAbsent(13, "int", "nested")
+	if set || !IsConflict(err) {
+		t.Errorf("SetIfAbsent over int parent returned %v, %v", set, err)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	hash := HashFromMap(map[string]interface{}{
+		"int": 10,
+	})
+
+	v, err := hash.SetDefault(11, "int")
+	if v != 10 || err != nil {
+		t.Errorf("SetDefault over existing value returned %v, %v", v, err)
+	}
+
+	v, err = hash.SetDefault(12, "map", "int")
+	if v != 12 || err != nil || hash.Get("map", "int") != 12 {
+		t.Errorf("SetDefault into empty place returned %v, %v", v, err)
+	}
+
+	_, err = hash.SetDefault(13, "int", "nested")
+	if !IsConflict(err) {
+		t.Errorf("SetDefault over int parent returned %v", err)
+	}
+}